@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"stock-api/repo"
+	"stock-api/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HeaderAPIKey is the header carrying the API key of machine clients.
+const HeaderAPIKey = "X-API-Key"
+
+// APIKeyIdentity resolves the caller from the X-API-Key header.
+// The key scopes are granted as roles and its last use is recorded.
+// Only unknown, wrong or inactive keys are invalid, failing to look up a key is an error.
+func APIKeyIdentity(keys repo.ApiKeyRepository) IdentityExtractor {
+	return func(c *gin.Context) (*Identity, error) {
+		prefix, secret, err := util.ParseAPIKey(c.GetHeader(HeaderAPIKey))
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := keys.GetApiKeyByPrefix(c.Request.Context(), prefix)
+		if errors.Is(err, repo.ErrApiKeyNotFound) {
+			return nil, util.ErrInvalidAPIKey
+		}
+		if err != nil {
+			return nil, fmt.Errorf("look up api key: %w", err)
+		}
+		if !util.VerifyAPIKeySecret(secret, key.SecretHash) {
+			return nil, util.ErrInvalidAPIKey
+		}
+
+		now := time.Now()
+		if !key.Active(now) {
+			return nil, util.ErrInvalidAPIKey
+		}
+
+		if err := keys.TouchApiKey(c.Request.Context(), key.ID, now); err != nil {
+			log.Println("failed to record api key usage:", err)
+		}
+
+		return &Identity{Subject: key.Owner, Roles: key.Scopes}, nil
+	}
+}
+
+// APIKeyOr resolves the caller from the X-API-Key header when it is present
+// and with fallback otherwise.
+func APIKeyOr(keys repo.ApiKeyRepository, fallback IdentityExtractor) IdentityExtractor {
+	byAPIKey := APIKeyIdentity(keys)
+	return func(c *gin.Context) (*Identity, error) {
+		if c.GetHeader(HeaderAPIKey) != "" {
+			return byAPIKey(c)
+		}
+		return fallback(c)
+	}
+}
@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"stock-api/repo"
+	"stock-api/util"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newAPIKeyRouter(keys repo.ApiKeyRepository) *gin.Engine {
+	r := gin.New()
+	r.Use(Problems(), Authenticate(APIKeyOr(keys, HeaderIdentity())))
+	r.POST("/stocks", RequireRoles(WriteRoles...), func(c *gin.Context) {
+		identity, _ := GetIdentity(c)
+		c.String(http.StatusCreated, identity.Subject)
+	})
+	return r
+}
+
+func TestAPIKeyIdentity(t *testing.T) {
+	key, prefix, secret, err := util.GenerateAPIKey()
+	assert.NoError(t, err)
+
+	// Create a mock repository holding the key
+	keys := new(repo.MockApiKeyRepo)
+	keys.On("GetApiKeyByPrefix", mock.Anything, prefix).Return(&repo.ApiKey{
+		ID:         7,
+		Prefix:     prefix,
+		SecretHash: util.HashAPIKeySecret(secret),
+		Owner:      "ingestion-bot",
+		Scopes:     repo.ApiKeyScopes{RoleEditor},
+	}, nil)
+	keys.On("TouchApiKey", mock.Anything, uint(7), mock.Anything).Return(nil)
+
+	req, err := http.NewRequest("POST", "/stocks", nil)
+	assert.NoError(t, err)
+	req.Header.Set(HeaderAPIKey, key)
+
+	w := httptest.NewRecorder()
+	newAPIKeyRouter(keys).ServeHTTP(w, req)
+
+	// The key owner is granted the key scopes and the usage is recorded
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "ingestion-bot", w.Body.String())
+	keys.AssertExpectations(t)
+}
+
+func TestAPIKeyIdentityRejectsInvalidKeys(t *testing.T) {
+	key, prefix, secret, err := util.GenerateAPIKey()
+	assert.NoError(t, err)
+	_, unknownPrefix, _, err := util.GenerateAPIKey()
+	assert.NoError(t, err)
+	expired := time.Now().Add(-time.Minute)
+
+	keys := new(repo.MockApiKeyRepo)
+	keys.On("GetApiKeyByPrefix", mock.Anything, prefix).Return(&repo.ApiKey{
+		ID:         7,
+		Prefix:     prefix,
+		SecretHash: util.HashAPIKeySecret(secret),
+		Owner:      "ingestion-bot",
+		Scopes:     repo.ApiKeyScopes{RoleEditor},
+		ExpiresAt:  &expired,
+	}, nil)
+	keys.On("GetApiKeyByPrefix", mock.Anything, unknownPrefix).Return((*repo.ApiKey)(nil), repo.ErrApiKeyNotFound)
+
+	for name, header := range map[string]string{
+		"malformed": "not-a-key",
+		"unknown":   "sk_" + unknownPrefix + "_" + secret,
+		"secret":    "sk_" + prefix + "_wrong-secret",
+		"expired":   key,
+	} {
+		req, err := http.NewRequest("POST", "/stocks", nil)
+		assert.NoError(t, err)
+		req.Header.Set(HeaderAPIKey, header)
+
+		w := httptest.NewRecorder()
+		newAPIKeyRouter(keys).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code, name)
+	}
+	keys.AssertNotCalled(t, "TouchApiKey", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAPIKeyIdentityLookupFailure(t *testing.T) {
+	key, prefix, _, err := util.GenerateAPIKey()
+	assert.NoError(t, err)
+
+	keys := new(repo.MockApiKeyRepo)
+	keys.On("GetApiKeyByPrefix", mock.Anything, prefix).Return((*repo.ApiKey)(nil), fmt.Errorf("%w: %w", repo.ErrUnavailable, context.DeadlineExceeded)).Once()
+	keys.On("GetApiKeyByPrefix", mock.Anything, prefix).Return((*repo.ApiKey)(nil), errors.New("syntax error"))
+
+	// A key that can't be looked up isn't reported as invalid
+	for _, status := range []int{http.StatusServiceUnavailable, http.StatusInternalServerError} {
+		req, err := http.NewRequest("POST", "/stocks", nil)
+		assert.NoError(t, err)
+		req.Header.Set(HeaderAPIKey, key)
+
+		w := httptest.NewRecorder()
+		newAPIKeyRouter(keys).ServeHTTP(w, req)
+
+		assert.Equal(t, status, w.Code)
+		assert.Equal(t, util.MIMEProblem, w.Header().Get("Content-Type"))
+	}
+	keys.AssertNotCalled(t, "TouchApiKey", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+
+	"stock-api/repo"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HeaderRequestID carries the ID of a request in both directions.
+const HeaderRequestID = "X-Request-ID"
+
+// ContextKeyRequestID is the gin context key holding the request ID.
+const ContextKeyRequestID = "requestId"
+
+// requestIDFormat bounds the request IDs accepted from clients.
+var requestIDFormat = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestID tags each request with the ID sent by the client, or a random one,
+// and echoes it in the response.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(HeaderRequestID)
+		if !requestIDFormat.MatchString(id) {
+			id = newRequestID()
+		}
+
+		c.Set(ContextKeyRequestID, id)
+		c.Header(HeaderRequestID, id)
+		c.Next()
+	}
+}
+
+// AuditContext records the caller and the request ID in the request context,
+// so the mutations made by the handlers are audited with them.
+// It must run after Authenticate.
+func AuditContext() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		info := repo.AuditInfo{RequestID: c.GetString(ContextKeyRequestID)}
+		if identity, ok := GetIdentity(c); ok {
+			info.Actor = identity.Subject
+		}
+
+		c.Request = c.Request.WithContext(repo.WithAuditInfo(c.Request.Context(), info))
+		c.Next()
+	}
+}
+
+func newRequestID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"stock-api/repo"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditContext(t *testing.T) {
+	var info repo.AuditInfo
+	r := gin.New()
+	r.Use(RequestID(), Authenticate(HeaderIdentity()), AuditContext())
+	r.POST("/stocks", func(c *gin.Context) {
+		info = repo.AuditInfoFromContext(c.Request.Context())
+		c.Status(http.StatusCreated)
+	})
+
+	serve := func(requestID string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("POST", "/stocks", nil)
+		assert.NoError(t, err)
+		req.Header.Set(HeaderUserID, "bob")
+		req.Header.Set(HeaderUserRole, RoleEditor)
+		req.Header.Set(HeaderRequestID, requestID)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// The request ID of the client is kept and echoed
+	w := serve("req-42")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "req-42", w.Header().Get(HeaderRequestID))
+	assert.Equal(t, repo.AuditInfo{Actor: "bob", RequestID: "req-42"}, info)
+
+	// A missing or malformed one is replaced
+	for _, requestID := range []string{"", "not a token\n"} {
+		w = serve(requestID)
+		generated := w.Header().Get(HeaderRequestID)
+		assert.Len(t, generated, 32)
+		assert.Equal(t, generated, info.RequestID)
+	}
+}
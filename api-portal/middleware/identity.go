@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"errors"
+
+	"stock-api/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContextKeyIdentity is the gin context key holding the authenticated *Identity.
+const ContextKeyIdentity = "identity"
+
+// Headers read by HeaderIdentity.
+const (
+	HeaderUserID   = "X-User-ID"
+	HeaderUserRole = "X-User-Role"
+)
+
+// Identity represents the caller of a request.
+type Identity struct {
+	Subject string
+	Roles   []string
+}
+
+// IdentityExtractor resolves the caller of a request.
+type IdentityExtractor func(c *gin.Context) (*Identity, error)
+
+// Authenticate rejects requests whose caller can't be resolved by extract
+// and exposes the identity to the next handlers. Invalid credentials are
+// unauthenticated, other errors are reported like the errors of the handlers.
+func Authenticate(extract IdentityExtractor) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity, err := extract(c)
+		if err != nil {
+			switch {
+			case errors.Is(err, util.ErrInvalidJWT):
+				util.AbortWithProblem(c, util.ProblemInvalidJWT, err.Error())
+			case errors.Is(err, util.ErrInvalidAPIKey):
+				util.AbortWithProblem(c, util.ProblemInvalidAPIKey, err.Error())
+			case errors.Is(err, util.ErrMissingIdentity):
+				util.AbortWithProblem(c, util.ProblemUnauthenticated, err.Error())
+			default:
+				util.AbortWithError(c, err)
+			}
+			return
+		}
+
+		c.Set(ContextKeyIdentity, identity)
+		c.Next()
+	}
+}
+
+// GetIdentity returns the identity set by Authenticate.
+func GetIdentity(c *gin.Context) (*Identity, bool) {
+	value, ok := c.Get(ContextKeyIdentity)
+	if !ok {
+		return nil, false
+	}
+	identity, ok := value.(*Identity)
+	return identity, ok
+}
+
+// HeaderIdentity trusts the X-User-ID and X-User-Role headers.
+// It is meant for local development only.
+func HeaderIdentity() IdentityExtractor {
+	return func(c *gin.Context) (*Identity, error) {
+		subject := c.GetHeader(HeaderUserID)
+		if util.IsEmptyOrBlankString(subject) {
+			return nil, util.ErrMissingIdentity
+		}
+
+		identity := &Identity{Subject: subject}
+		if role := util.TrimSpaceToLower(c.GetHeader(HeaderUserRole)); role != "" {
+			identity.Roles = []string{role}
+		}
+		return identity, nil
+	}
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"strings"
+
+	"stock-api/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+const bearerPrefix = "Bearer "
+
+// JWTIdentity resolves the caller from a HS256 bearer token signed with secretKey.
+func JWTIdentity(secretKey string) IdentityExtractor {
+	return func(c *gin.Context) (*Identity, error) {
+		header := c.GetHeader("Authorization")
+		if !strings.HasPrefix(header, bearerPrefix) {
+			return nil, util.ErrInvalidJWT
+		}
+
+		claims, err := util.ParseJWT(strings.TrimPrefix(header, bearerPrefix), secretKey)
+		if err != nil {
+			return nil, err
+		}
+
+		identity := &Identity{Subject: claims.Subject}
+		if claims.Role != "" {
+			identity.Roles = []string{claims.Role}
+		}
+		return identity, nil
+	}
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"stock-api/util"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+const testSecretKey = "test-secret"
+
+func newJWTRouter() *gin.Engine {
+	r := gin.New()
+	r.GET("/protected", Authenticate(JWTIdentity(testSecretKey)), func(c *gin.Context) {
+		identity, _ := GetIdentity(c)
+		c.String(http.StatusOK, identity.Subject+":"+identity.Roles[0])
+	})
+	return r
+}
+
+func TestJWTIdentity(t *testing.T) {
+	r := newJWTRouter()
+
+	// Issue a token for the request
+	token, _, err := util.GenerateJWT("alice", RoleEditor, testSecretKey, time.Hour)
+	assert.NoError(t, err)
+
+	req, err := http.NewRequest("GET", "/protected", nil)
+	assert.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	// The subject and role of the token are exposed to the handler
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "alice:editor", w.Body.String())
+}
+
+func TestJWTIdentityRejectsInvalidTokens(t *testing.T) {
+	r := newJWTRouter()
+
+	expired, _, err := util.GenerateJWT("alice", RoleEditor, testSecretKey, -time.Minute)
+	assert.NoError(t, err)
+	foreign, _, err := util.GenerateJWT("alice", RoleEditor, "another-secret", time.Hour)
+	assert.NoError(t, err)
+
+	for name, header := range map[string]string{
+		"missing": "",
+		"garbage": "Bearer not-a-token",
+		"expired": "Bearer " + expired,
+		"foreign": "Bearer " + foreign,
+	} {
+		req, err := http.NewRequest("GET", "/protected", nil)
+		assert.NoError(t, err)
+		req.Header.Set("Authorization", header)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code, name)
+	}
+}
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"stock-api/repo"
+	"stock-api/util"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Problems writes the error a request was aborted with as an RFC 7807 problem.
+// Errors that aren't a util.ProblemError are mapped from their cause: missing
+// records to 404, unique violations to 409, anything unexpected to 500.
+// It must run before the handlers that may fail, and after RequestID.
+func Problems() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		err := c.Errors.Last().Err
+		problem := newProblem(c, err)
+		if problem.Status >= http.StatusInternalServerError {
+			log.Printf("%s %s failed: %v", c.Request.Method, c.Request.URL.Path, err)
+		}
+		util.WriteProblem(c, problem)
+	}
+}
+
+// newProblem returns the problem reporting an error to the client.
+// The error message itself is never disclosed, only its detail.
+func newProblem(c *gin.Context, err error) util.Problem {
+	var problemErr *util.ProblemError
+	if !errors.As(err, &problemErr) {
+		problemErr = &util.ProblemError{Err: err}
+	}
+
+	problemType, detail := problemErr.Type, problemErr.Detail
+	if problemType.Status == 0 {
+		problemType = problemTypeOf(err)
+		// the detail was written for an expected cause
+		if problemType == util.ProblemInternal {
+			detail = ""
+		}
+	}
+
+	problem := util.NewProblem(c, problemType, detail)
+	problem.Fields = problemErr.Fields
+	problem.RequestID = c.GetString(ContextKeyRequestID)
+	return problem
+}
+
+// problemTypeOf maps the errors of the repositories to the error catalog.
+func problemTypeOf(err error) util.ProblemType {
+	switch {
+	case errors.Is(err, repo.ErrUnavailable):
+		return util.ProblemUnavailable
+	case errors.Is(err, repo.ErrStockNotFound), errors.Is(err, gorm.ErrRecordNotFound), errors.Is(err, repo.ErrApiKeyNotFound):
+		return util.ProblemNotFound
+	case errors.Is(err, repo.ErrConflict), errors.Is(err, gorm.ErrDuplicatedKey):
+		return util.ProblemConflict
+	case errors.Is(err, repo.ErrVersionConflict):
+		return util.ProblemPreconditionFailed
+	case errors.Is(err, repo.ErrFxRateNotFound):
+		return util.ProblemBadRequest
+	}
+	return util.ProblemInternal
+}
+
+// NoRoute reports the requests matching no route as a problem.
+func NoRoute(c *gin.Context) {
+	util.AbortWithProblem(c, util.ProblemNotFound, "No route for "+c.Request.Method+" "+c.Request.URL.Path)
+}
+
+// Recovery reports the panics of the handlers as internal errors.
+// It must run after Problems.
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecovery(func(c *gin.Context, recovered interface{}) {
+		util.AbortWithError(c, fmt.Errorf("panic: %v", recovered))
+	})
+}
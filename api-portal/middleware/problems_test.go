@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"stock-api/repo"
+	"stock-api/util"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestProblems(t *testing.T) {
+	r := gin.New()
+	r.Use(RequestID(), Problems(), Recovery())
+	r.NoRoute(NoRoute)
+
+	fail := func(path string, err error) {
+		r.GET(path, func(c *gin.Context) { util.AbortWithError(c, err) })
+	}
+	fail("/missing", util.WithDetail(fmt.Errorf("find: %w", gorm.ErrRecordNotFound), "Stock not found"))
+	fail("/duplicate", gorm.ErrDuplicatedKey)
+	fail("/stale", repo.ErrVersionConflict)
+	fail("/down", fmt.Errorf("%w: %w", repo.ErrUnavailable, errors.New("connection refused")))
+	fail("/broken", util.WithDetail(errors.New("connection refused on 10.0.0.1"), "Stock not found"))
+	fail("/invalid", &util.ProblemError{Type: util.ProblemValidation, Fields: util.FieldErrors{{Field: "name", Message: "is required"}}})
+	r.GET("/panic", func(c *gin.Context) { panic("boom") })
+
+	serve := func(path string) (*httptest.ResponseRecorder, util.Problem) {
+		req, err := http.NewRequest("GET", path, nil)
+		assert.NoError(t, err)
+		req.Header.Set(HeaderRequestID, "req-1")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		var problem util.Problem
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem), path)
+		assert.Equal(t, util.MIMEProblem, w.Header().Get("Content-Type"), path)
+		assert.Equal(t, w.Code, problem.Status, path)
+		assert.Equal(t, "req-1", problem.RequestID, path)
+		return w, problem
+	}
+
+	// Repository errors are mapped from their cause
+	_, problem := serve("/missing")
+	assert.Equal(t, util.Problem{
+		Type:      "urn:stock-api:problem:not-found",
+		Title:     "Not found",
+		Status:    http.StatusNotFound,
+		Detail:    "Stock not found",
+		Instance:  "/missing",
+		Code:      "NOT_FOUND",
+		RequestID: "req-1",
+	}, problem)
+
+	w, problem := serve("/duplicate")
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, "CONFLICT", problem.Code)
+
+	w, _ = serve("/stale")
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+
+	w, problem = serve("/down")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "SERVICE_UNAVAILABLE", problem.Code)
+	assert.Empty(t, problem.Detail)
+
+	// Unexpected errors are never disclosed
+	w, problem = serve("/broken")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Empty(t, problem.Detail)
+	assert.NotContains(t, w.Body.String(), "10.0.0.1")
+
+	w, _ = serve("/panic")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	// Validation problems list the invalid fields
+	w, problem = serve("/invalid")
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, []util.FieldError{{Field: "name", Message: "is required"}}, problem.Fields)
+
+	w, problem = serve("/nowhere")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "No route for GET /nowhere", problem.Detail)
+}
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"stock-api/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Roles granted to callers.
+const (
+	RoleAdmin  = "admin"
+	RoleEditor = "editor"
+	RoleViewer = "viewer"
+)
+
+var (
+	// ReadRoles may call read-only endpoints.
+	ReadRoles = []string{RoleAdmin, RoleEditor, RoleViewer}
+	// WriteRoles may call mutating endpoints.
+	WriteRoles = []string{RoleAdmin, RoleEditor}
+	// AdminRoles may call administrative endpoints.
+	AdminRoles = []string{RoleAdmin}
+)
+
+// IsRole reports whether role is one of the known roles.
+func IsRole(role string) bool {
+	return role == RoleAdmin || role == RoleEditor || role == RoleViewer
+}
+
+// HasRole reports whether the identity was granted any of the roles.
+func (i *Identity) HasRole(roles ...string) bool {
+	for _, granted := range i.Roles {
+		for _, role := range roles {
+			if granted == role {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Route pairs an endpoint with the roles allowed to call it.
+type Route struct {
+	Method  string
+	Path    string
+	Handler gin.HandlerFunc
+	Roles   []string
+}
+
+// RegisterRoutes registers each route behind a RequireRoles check of its roles.
+func RegisterRoutes(router gin.IRouter, routes []Route) {
+	for _, r := range routes {
+		router.Handle(r.Method, r.Path, RequireRoles(r.Roles...), r.Handler)
+	}
+}
+
+// RequireRoles rejects callers that weren't granted any of the roles.
+// It must run after Authenticate.
+func RequireRoles(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity, ok := GetIdentity(c)
+		if !ok {
+			util.AbortWithProblem(c, util.ProblemUnauthenticated, util.ErrMissingIdentity.Error())
+			return
+		}
+
+		if !identity.HasRole(roles...) {
+			util.AbortWithProblem(c, util.ProblemForbidden, "Insufficient role")
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireRoles(t *testing.T) {
+	r := gin.New()
+	r.Use(Authenticate(HeaderIdentity()))
+	r.GET("/stocks", RequireRoles(ReadRoles...), func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.POST("/stocks", RequireRoles(WriteRoles...), func(c *gin.Context) { c.Status(http.StatusCreated) })
+
+	for _, tc := range []struct {
+		method string
+		userID string
+		role   string
+		code   int
+	}{
+		{"GET", "", "", http.StatusUnauthorized},
+		{"GET", "bob", "", http.StatusForbidden},
+		{"GET", "bob", RoleViewer, http.StatusOK},
+		{"POST", "bob", RoleViewer, http.StatusForbidden},
+		{"POST", "bob", RoleEditor, http.StatusCreated},
+		{"POST", "bob", "Admin", http.StatusCreated},
+	} {
+		req, err := http.NewRequest(tc.method, "/stocks", nil)
+		assert.NoError(t, err)
+		req.Header.Set(HeaderUserID, tc.userID)
+		req.Header.Set(HeaderUserRole, tc.role)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, tc.code, w.Code, "%s as %q", tc.method, tc.role)
+	}
+}
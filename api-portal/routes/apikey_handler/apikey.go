@@ -0,0 +1,148 @@
+package apikey_handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"stock-api/api-portal/middleware"
+	"stock-api/repo"
+	"stock-api/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateApiKeyRequest represents the API key to mint.
+type CreateApiKeyRequest struct {
+	Owner     string     `json:"owner" binding:"required"`
+	Scopes    []string   `json:"scopes" binding:"required"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+// CreateApiKeyResponse represents a minted API key.
+// The key is only ever returned by this response.
+type CreateApiKeyResponse struct {
+	Key    string      `json:"key"`
+	ApiKey repo.ApiKey `json:"apiKey"`
+}
+
+// ApiKeyHandler serves the api key endpoints from an ApiKeyRepository.
+type ApiKeyHandler struct {
+	Repo repo.ApiKeyRepository
+}
+
+// NewApiKeyHandler initializes a new ApiKeyHandler with a repository.
+func NewApiKeyHandler(apiKeyRepo repo.ApiKeyRepository) *ApiKeyHandler {
+	return &ApiKeyHandler{Repo: apiKeyRepo}
+}
+
+// @Summary List API keys
+// @Description Retrieves every API key, without their secrets.
+// @Accept json
+// @Produce json
+// @Success 200 {array} repo.ApiKey
+// @Failure 401 {object} util.Problem
+// @Failure 403 {object} util.Problem
+// @Failure 500 {object} util.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api-keys [get]
+func (h *ApiKeyHandler) GetApiKeys(c *gin.Context) {
+	keys, err := h.Repo.GetApiKeys(c.Request.Context())
+	if err != nil {
+		util.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+// @Summary Mint an API key
+// @Description Creates an API key for a machine client. Scopes are the roles granted to the key.
+// @Accept json
+// @Produce json
+// @Param apiKey body CreateApiKeyRequest true "API key to create"
+// @Success 201 {object} CreateApiKeyResponse
+// @Failure 400 {object} util.Problem
+// @Failure 401 {object} util.Problem
+// @Failure 403 {object} util.Problem
+// @Failure 500 {object} util.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api-keys [post]
+func (h *ApiKeyHandler) CreateApiKey(c *gin.Context) {
+	var req CreateApiKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.AbortWithProblem(c, util.ProblemMalformedJSON, "Invalid JSON body, expected an object with an owner and scopes")
+		return
+	}
+
+	if len(req.Scopes) == 0 {
+		util.AbortWithProblem(c, util.ProblemBadRequest, "At least one scope is required")
+		return
+	}
+	for _, scope := range req.Scopes {
+		if !middleware.IsRole(scope) {
+			util.AbortWithProblem(c, util.ProblemBadRequest, "Unknown scope: "+scope)
+			return
+		}
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		util.AbortWithProblem(c, util.ProblemBadRequest, "Expiry must be in the future")
+		return
+	}
+
+	key, prefix, secret, err := util.GenerateAPIKey()
+	if err != nil {
+		util.AbortWithError(c, err)
+		return
+	}
+
+	apiKey := repo.ApiKey{
+		Prefix:     prefix,
+		SecretHash: util.HashAPIKeySecret(secret),
+		Owner:      req.Owner,
+		Scopes:     req.Scopes,
+		ExpiresAt:  req.ExpiresAt,
+	}
+	if err := h.Repo.CreateApiKey(c.Request.Context(), &apiKey); err != nil {
+		util.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, CreateApiKeyResponse{Key: key, ApiKey: apiKey})
+}
+
+// @Summary Revoke an API key
+// @Description Revokes an API key so it can no longer authenticate.
+// @Accept json
+// @Produce json
+// @Param id path int true "API key ID"
+// @Success 200 {object} util.MessageResponse
+// @Failure 400 {object} util.Problem
+// @Failure 401 {object} util.Problem
+// @Failure 403 {object} util.Problem
+// @Failure 404 {object} util.Problem
+// @Failure 500 {object} util.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api-keys/{id} [delete]
+func (h *ApiKeyHandler) RevokeApiKey(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		util.AbortWithProblem(c, util.ProblemBadRequest, "Invalid api key ID")
+		return
+	}
+
+	if err := h.Repo.RevokeApiKey(c.Request.Context(), uint(id)); err != nil {
+		if errors.Is(err, repo.ErrApiKeyNotFound) {
+			err = util.WithDetail(err, "Api key not found")
+		}
+		util.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, util.MessageResponse{Message: "Api key revoked successfully"})
+}
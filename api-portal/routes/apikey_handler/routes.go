@@ -0,0 +1,22 @@
+package apikey_handler
+
+import (
+	"net/http"
+
+	"stock-api/api-portal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// routes is the permission table of the api key endpoints.
+func (h *ApiKeyHandler) routes() []middleware.Route {
+	return []middleware.Route{
+		{Method: http.MethodGet, Path: "/api/api-keys", Handler: h.GetApiKeys, Roles: middleware.AdminRoles},
+		{Method: http.MethodPost, Path: "/api/api-keys", Handler: h.CreateApiKey, Roles: middleware.AdminRoles},
+		{Method: http.MethodDelete, Path: "/api/api-keys/:id", Handler: h.RevokeApiKey, Roles: middleware.AdminRoles},
+	}
+}
+
+func RegisterRoutes(router gin.IRouter, h *ApiKeyHandler) {
+	middleware.RegisterRoutes(router, h.routes())
+}
@@ -0,0 +1,90 @@
+package audit_handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"stock-api/repo"
+	"stock-api/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxPageSize bounds the number of events per page.
+const maxPageSize = 100
+
+// AuditHandler serves the audit log from an AuditRepository.
+type AuditHandler struct {
+	Repo repo.AuditRepository
+}
+
+// NewAuditHandler initializes a new AuditHandler with a repository.
+func NewAuditHandler(auditRepo repo.AuditRepository) *AuditHandler {
+	return &AuditHandler{Repo: auditRepo}
+}
+
+// @Summary Get the audit log
+// @Description Retrieves a page of audit events, newest first, along with the total count, and first, prev, next and last links in the Link header.
+// @Description Each event tells who made a mutation, in which request, and the fields it changed.
+// @Accept json
+// @Produce json
+// @Param entity query string false "Keep the events of this entity: stock"
+// @Param id query string false "Keep the events of the entity with this ID, requires entity"
+// @Param page query int false "Page number (default is 1)"
+// @Param pageSize query int false "Number of events per page (default is 10, at most 100)"
+// @Success 200 {object} util.PageResponse{data=[]repo.AuditEvent}
+// @Header 200 {string} Link "RFC 8288 links to the other pages"
+// @Failure 400 {object} util.Problem
+// @Failure 401 {object} util.Problem
+// @Failure 403 {object} util.Problem
+// @Failure 500 {object} util.Problem
+// @Failure 503 {object} util.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /audit [get]
+func (h *AuditHandler) GetAuditEvents(c *gin.Context) {
+	query := repo.AuditQuery{Entity: c.Query("entity")}
+	if query.Entity != "" && query.Entity != repo.AuditEntityStock {
+		util.AbortWithProblem(c, util.ProblemBadRequest, "Invalid entity")
+		return
+	}
+
+	if id := c.Query("id"); id != "" {
+		entityID, err := util.DecodeID(id)
+		if err != nil || query.Entity == "" {
+			util.AbortWithProblem(c, util.ProblemBadRequest, "Invalid id")
+			return
+		}
+		query.EntityID = entityID
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		util.AbortWithProblem(c, util.ProblemBadRequest, "Invalid page")
+		return
+	}
+
+	pageSize, err := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+	if err != nil || pageSize < 1 || pageSize > maxPageSize {
+		util.AbortWithProblem(c, util.ProblemBadRequest, "Invalid page size")
+		return
+	}
+
+	query.Offset = (page - 1) * pageSize
+	query.Limit = pageSize
+	events, err := h.Repo.FindAuditEvents(c.Request.Context(), query)
+	if err != nil {
+		util.AbortWithError(c, err)
+		return
+	}
+
+	total, err := h.Repo.CountAuditEvents(c.Request.Context(), query)
+	if err != nil {
+		util.AbortWithError(c, err)
+		return
+	}
+
+	response := util.NewPageResponse(events, page, pageSize, total)
+	util.SetPageLinks(c, response)
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,59 @@
+package audit_handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"stock-api/api-portal/middleware"
+	"stock-api/repo"
+	"stock-api/util"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm/logger"
+)
+
+func TestAuditEventsUnavailable(t *testing.T) {
+	db := &repo.Database{
+		Driver:   repo.DriverSQLite,
+		DbPath:   filepath.Join(t.TempDir(), "stock.db"),
+		LogLevel: logger.Silent,
+	}
+	db.Connect()
+	t.Cleanup(db.Close)
+	migrator, err := repo.NewMigrator(db.DB())
+	assert.NoError(t, err)
+	_, err = migrator.Up(context.Background())
+	assert.NoError(t, err)
+
+	r := gin.New()
+	r.Use(middleware.Problems())
+	RegisterRoutes(r.Group("", func(c *gin.Context) {
+		c.Set(middleware.ContextKeyIdentity, &middleware.Identity{Subject: "test", Roles: []string{middleware.RoleAdmin}})
+	}), NewAuditHandler(repo.NewAuditRepo(db)))
+
+	// The query times out as the database doesn't answer in time
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/api/audit", nil)
+	assert.NoError(t, err)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	var problem util.Problem
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, util.ProblemUnavailable.Code, problem.Code)
+
+	// Without the timeout the log is served
+	req, err = http.NewRequest(http.MethodGet, "/api/audit", nil)
+	assert.NoError(t, err)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
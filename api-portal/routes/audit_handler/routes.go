@@ -0,0 +1,20 @@
+package audit_handler
+
+import (
+	"net/http"
+
+	"stock-api/api-portal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// routes is the permission table of the audit endpoints.
+func (h *AuditHandler) routes() []middleware.Route {
+	return []middleware.Route{
+		{Method: http.MethodGet, Path: "/api/audit", Handler: h.GetAuditEvents, Roles: middleware.AdminRoles},
+	}
+}
+
+func RegisterRoutes(router gin.IRouter, h *AuditHandler) {
+	middleware.RegisterRoutes(router, h.routes())
+}
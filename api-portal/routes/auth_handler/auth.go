@@ -0,0 +1,69 @@
+package auth_handler
+
+import (
+	"net/http"
+	"time"
+
+	"stock-api/global"
+	"stock-api/util"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// TokenRequest represents the credentials exchanged for an access token.
+type TokenRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// TokenResponse represents an issued access token.
+type TokenResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// @Summary Issue an access token
+// @Description Exchanges user credentials for a HS256 JWT to send as a Bearer token.
+// @Accept json
+// @Produce json
+// @Param credentials body TokenRequest true "User credentials"
+// @Success 200 {object} TokenResponse
+// @Failure 400 {object} util.Problem
+// @Failure 401 {object} util.Problem
+// @Failure 500 {object} util.Problem
+// @Router /auth/token [post]
+func IssueToken(c *gin.Context) {
+	var req TokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.AbortWithProblem(c, util.ProblemMalformedJSON, "Invalid JSON body, expected a username and a password")
+		return
+	}
+
+	config := global.GetEnvConfig()
+	user, ok := config.AuthUsers[req.Username]
+	if !validPassword(user.PasswordHash, req.Password) || !ok {
+		util.AbortWithProblem(c, util.ProblemInvalidCredentials, util.ErrInvalidCredentials.Error())
+		return
+	}
+
+	token, expiresAt, err := util.GenerateJWT(req.Username, user.Role, config.SecretKey, config.DefaultJWTDuration)
+	if err != nil {
+		util.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, TokenResponse{Token: token, ExpiresAt: expiresAt})
+}
+
+// unknownUserHash is compared against the passwords of unknown users,
+// so they take as long to reject as wrong passwords.
+const unknownUserHash = "$2a$10$lzfzb4u0ux0hLTqo4bR9OOvnrFmltHO8SxeYIGqfU0A9.yzGljPa2"
+
+// validPassword checks a password against its bcrypt hash.
+func validPassword(hash, password string) bool {
+	if hash == "" {
+		hash = unknownUserHash
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
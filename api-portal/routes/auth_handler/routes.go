@@ -0,0 +1,9 @@
+package auth_handler
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(router gin.IRouter) {
+	router.POST("/api/auth/token", IssueToken)
+}
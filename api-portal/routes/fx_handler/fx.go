@@ -0,0 +1,113 @@
+package fx_handler
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"stock-api/repo"
+	"stock-api/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FxHandler serves the exchange rate endpoints from an FxRateRepository.
+type FxHandler struct {
+	Repo repo.FxRateRepository
+}
+
+// NewFxHandler initializes a new FxHandler with a repository.
+func NewFxHandler(fxRateRepo repo.FxRateRepository) *FxHandler {
+	return &FxHandler{Repo: fxRateRepo}
+}
+
+// @Summary List exchange rates
+// @Description Retrieves the exchange rate of every currency pair used to convert prices.
+// @Accept json
+// @Produce json
+// @Success 200 {array} repo.FxRate
+// @Failure 401 {object} util.Problem
+// @Failure 403 {object} util.Problem
+// @Failure 500 {object} util.Problem
+// @Failure 503 {object} util.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /fx-rates [get]
+func (h *FxHandler) GetFxRates(c *gin.Context) {
+	rates, err := h.Repo.GetFxRates(c.Request.Context())
+	if err != nil {
+		util.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rates)
+}
+
+// @Summary Load exchange rates
+// @Description Stores exchange rates, replacing the current rate of their currency pair.
+// @Description A rate is the price of one unit of base in quote, asOf defaults to now.
+// @Description Each currency pair may only appear once.
+// @Accept json
+// @Produce json
+// @Param rates body []repo.FxRate true "Exchange rates to load"
+// @Success 200 {array} repo.FxRate
+// @Failure 400 {object} util.Problem
+// @Failure 401 {object} util.Problem
+// @Failure 403 {object} util.Problem
+// @Failure 422 {object} util.Problem
+// @Failure 500 {object} util.Problem
+// @Failure 503 {object} util.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /fx-rates [put]
+func (h *FxHandler) LoadFxRates(c *gin.Context) {
+	var rates []repo.FxRate
+	if err := c.ShouldBindJSON(&rates); err != nil {
+		util.AbortWithProblem(c, util.ProblemMalformedJSON, "Invalid JSON body, expected an array of rates")
+		return
+	}
+
+	if len(rates) == 0 {
+		util.AbortWithProblem(c, util.ProblemBadRequest, "At least one rate is required")
+		return
+	}
+
+	now := time.Now().UTC()
+	var errs util.FieldErrors
+	pairs := map[[2]string]int{}
+	for i := range rates {
+		rate := &rates[i]
+		rate.Base = repo.NormalizeIdentifier(rate.Base)
+		rate.Quote = repo.NormalizeIdentifier(rate.Quote)
+		if !repo.IsCurrencyCode(rate.Base) || !repo.IsCurrencyCode(rate.Quote) || rate.Base == rate.Quote {
+			util.AbortWithProblem(c, util.ProblemBadRequest, "Invalid currency pair, expected two different ISO 4217 codes")
+			return
+		}
+		if rate.Rate.IsNegative() || rate.Rate.IsZero() {
+			util.AbortWithProblem(c, util.ProblemBadRequest, "Invalid rate, expected a positive number")
+			return
+		}
+		if rate.AsOf.IsZero() {
+			rate.AsOf = now
+		}
+
+		// The upsert can't replace the same pair twice in one statement
+		pair := [2]string{rate.Base, rate.Quote}
+		if first, ok := pairs[pair]; ok {
+			errs.Add(fmt.Sprintf("[%d]", i), fmt.Sprintf("repeats the %s/%s pair of rate %d", rate.Base, rate.Quote, first))
+			continue
+		}
+		pairs[pair] = i
+	}
+	if len(errs) > 0 {
+		util.AbortWithError(c, &util.ProblemError{Type: util.ProblemValidation, Detail: "Duplicate currency pairs", Fields: errs})
+		return
+	}
+
+	if err := h.Repo.SaveFxRates(c.Request.Context(), rates); err != nil {
+		util.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rates)
+}
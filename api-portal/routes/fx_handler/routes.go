@@ -0,0 +1,21 @@
+package fx_handler
+
+import (
+	"net/http"
+
+	"stock-api/api-portal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// routes is the permission table of the exchange rate endpoints.
+func (h *FxHandler) routes() []middleware.Route {
+	return []middleware.Route{
+		{Method: http.MethodGet, Path: "/api/fx-rates", Handler: h.GetFxRates, Roles: middleware.ReadRoles},
+		{Method: http.MethodPut, Path: "/api/fx-rates", Handler: h.LoadFxRates, Roles: middleware.AdminRoles},
+	}
+}
+
+func RegisterRoutes(router gin.IRouter, h *FxHandler) {
+	middleware.RegisterRoutes(router, h.routes())
+}
@@ -5,9 +5,15 @@ import (
 	"log"
 	"net/http"
 
+	"stock-api/api-portal/middleware"
+	"stock-api/api-portal/routes/apikey_handler"
+	"stock-api/api-portal/routes/audit_handler"
+	"stock-api/api-portal/routes/auth_handler"
+	"stock-api/api-portal/routes/fx_handler"
 	"stock-api/api-portal/routes/health_handler"
 	"stock-api/api-portal/routes/stock_handler"
 	"stock-api/global"
+	"stock-api/repo"
 
 	"github.com/gin-gonic/gin"
 	// swaggerFiles "github.com/swaggo/files"
@@ -21,12 +27,21 @@ import (
 func Init() {
 	var port = global.Config.ServerPort
 	router := gin.New()
-	router.Use()
+	// every error response is an RFC 7807 problem
+	router.Use(middleware.RequestID(), middleware.Problems(), middleware.Recovery())
+	router.NoRoute(middleware.NoRoute)
 
 	gin.SetMode(gin.DebugMode)
 	// register our routes
 	health_handler.RegisterRoutes(router)
-	stock_handler.RegisterRoutes(router)
+	auth_handler.RegisterRoutes(router)
+
+	// routes below require an identified caller
+	authorized := router.Group("", middleware.Authenticate(identityExtractor(global.Config, repo.Server.ApiKeyRepo)), middleware.AuditContext())
+	stock_handler.RegisterRoutes(authorized, stock_handler.NewStockHandler(repo.Server.StockRepo, repo.Server.FxRateRepo))
+	apikey_handler.RegisterRoutes(authorized, apikey_handler.NewApiKeyHandler(repo.Server.ApiKeyRepo))
+	fx_handler.RegisterRoutes(authorized, fx_handler.NewFxHandler(repo.Server.FxRateRepo))
+	audit_handler.RegisterRoutes(authorized, audit_handler.NewAuditHandler(repo.Server.AuditRepo))
 
 	// Serve Swagger UI at /swagger
 	router.GET("/docs/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
@@ -36,3 +51,23 @@ func Init() {
 		log.Fatal("Fatal Can't ListenAndServe: ", err)
 	}
 }
+
+// identityExtractor picks how callers are identified from the auth mode.
+// Machine clients may always authenticate with an API key.
+func identityExtractor(config *global.VecConfig, apiKeys repo.ApiKeyRepository) middleware.IdentityExtractor {
+	var extractor middleware.IdentityExtractor
+	switch config.AuthMode {
+	case "jwt":
+		if config.SecretKey == "" {
+			log.Fatal("JWT_KEY must be set to sign access tokens")
+		}
+		extractor = middleware.JWTIdentity(config.SecretKey)
+	case "header":
+		log.Println("AUTH_MODE=header trusts the X-User-ID and X-User-Role headers, use it in development only")
+		extractor = middleware.HeaderIdentity()
+	default:
+		log.Fatalf("unknown AUTH_MODE %q", config.AuthMode)
+	}
+
+	return middleware.APIKeyOr(apiKeys, extractor)
+}
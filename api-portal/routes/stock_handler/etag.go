@@ -0,0 +1,49 @@
+package stock_handler
+
+import (
+	"strconv"
+	"strings"
+
+	"stock-api/repo"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	headerETag    = "ETag"
+	headerIfMatch = "If-Match"
+)
+
+// stockETag returns the strong entity tag of a version of a stock.
+func stockETag(stock *repo.Stock) string {
+	return `"` + strconv.FormatUint(uint64(stock.Version), 10) + `"`
+}
+
+// setStockETag sets the ETag header of a response holding a stock.
+func setStockETag(c *gin.Context, stock *repo.Stock) {
+	c.Header(headerETag, stockETag(stock))
+}
+
+// setConvertedStockETag sets the ETag header of a response holding a stock converted
+// into another currency. The converted stock gets a weak tag, as it differs from the
+// stock and depends on the exchange rate too: only the stock can be sent in If-Match.
+func setConvertedStockETag(c *gin.Context, stock, converted *repo.Stock) {
+	if converted.Fx == nil {
+		setStockETag(c, stock)
+		return
+	}
+	c.Header(headerETag, "W/"+stockETag(stock))
+}
+
+// matchesIfMatch reports whether an If-Match header matches the current version
+// of a stock, using the strong comparison of RFC 9110: weak tags never match.
+func matchesIfMatch(header string, stock *repo.Stock) bool {
+	etag := stockETag(stock)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package stock_handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"stock-api/repo"
+	"stock-api/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageSize = 10
+	maxPageSize     = 100
+)
+
+// StockCursorPage is a page of stocks walked by cursor.
+type StockCursorPage struct {
+	Data []repo.Stock `json:"data"`
+	// NextCursor resumes after the last stock of the page, null on the last page
+	NextCursor *string `json:"nextCursor"`
+}
+
+// isCursorQuery reports whether the request asks for cursor pagination
+// rather than the legacy page and pageSize.
+func isCursorQuery(c *gin.Context) bool {
+	_, cursor := c.GetQuery("cursor")
+	_, limit := c.GetQuery("limit")
+	return cursor || limit
+}
+
+// parseLimitQuery parses the optional limit query parameter, between 1 and maxPageSize.
+func parseLimitQuery(c *gin.Context) (int, bool) {
+	value := c.Query("limit")
+	if value == "" {
+		return defaultPageSize, true
+	}
+
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 || limit > maxPageSize {
+		return 0, false
+	}
+	return limit, true
+}
+
+// getStocksByCursor serves a page of the stocks matching query following the cursor,
+// the first page without one, priced in currency when set. Stocks are walked by ID
+// so they can't be sorted.
+func (h *StockHandler) getStocksByCursor(c *gin.Context, query repo.StockQuery, currency string) {
+	if len(query.Sort) > 0 {
+		util.AbortWithProblem(c, util.ProblemBadRequest, "Sort can't be combined with a cursor")
+		return
+	}
+
+	limit, ok := parseLimitQuery(c)
+	if !ok {
+		util.AbortWithProblem(c, util.ProblemBadRequest, "Invalid limit")
+		return
+	}
+
+	if cursor := c.Query("cursor"); cursor != "" {
+		afterID, err := util.DecodeCursor(cursor)
+		if err != nil {
+			util.AbortWithProblem(c, util.ProblemBadRequest, "Invalid cursor")
+			return
+		}
+		query.AfterID = afterID
+	}
+
+	// Fetch one more stock than needed to know whether a next page exists
+	query.Limit = limit + 1
+	stocks, err := h.Repo.FindStocks(c.Request.Context(), query)
+	if err != nil {
+		util.AbortWithError(c, err)
+		return
+	}
+
+	page := StockCursorPage{Data: stocks}
+	if len(stocks) > limit {
+		page.Data = stocks[:limit]
+		next := util.EncodeCursor(uint(page.Data[limit-1].ID))
+		page.NextCursor = &next
+	}
+	if page.Data == nil {
+		page.Data = []repo.Stock{}
+	}
+
+	if !h.convertStocks(c, page.Data, currency) {
+		return
+	}
+
+	if page.NextCursor != nil {
+		c.Header("Link", util.PageLink(c, "cursor", *page.NextCursor, "next"))
+	}
+
+	c.JSON(http.StatusOK, page)
+}
@@ -0,0 +1,69 @@
+package stock_handler
+
+import (
+	"strconv"
+
+	"stock-api/repo"
+	"stock-api/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+const invalidCurrencyMessage = "Invalid currency, expected an ISO 4217 code"
+
+// parseStockQuery parses the filters and sort of a stock list request.
+// It returns the message of the bad request when a parameter is invalid.
+func parseStockQuery(c *gin.Context) (repo.StockQuery, string) {
+	query := repo.StockQuery{Name: c.Query("name")}
+
+	var ok bool
+	if query.MinPrice, ok = parsePriceQuery(c, "minPrice"); !ok {
+		return query, "Invalid minPrice"
+	}
+	if query.MaxPrice, ok = parsePriceQuery(c, "maxPrice"); !ok {
+		return query, "Invalid maxPrice"
+	}
+	if query.MinPrice != nil && query.MaxPrice != nil && query.MinPrice.Cmp(*query.MaxPrice) > 0 {
+		return query, "minPrice is greater than maxPrice"
+	}
+
+	updatedSince, err := parseTimeQuery(c, "updatedSince")
+	if err != nil {
+		return query, "Invalid updatedSince, expected RFC 3339"
+	}
+	query.UpdatedSince = updatedSince
+
+	if query.IncludeDeleted, err = strconv.ParseBool(c.DefaultQuery("includeDeleted", "false")); err != nil {
+		return query, "Invalid includeDeleted"
+	}
+
+	if query.Sort, err = repo.ParseStockSort(c.Query("sort")); err != nil {
+		return query, "Invalid sort, expected a comma separated list of id, name, currentPrice or lastUpdate, prefixed with - to sort descending"
+	}
+	return query, ""
+}
+
+// parsePriceQuery parses an optional price query parameter.
+// A missing parameter yields nil.
+func parsePriceQuery(c *gin.Context, key string) (*util.Decimal, bool) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, true
+	}
+
+	price, err := util.ParseDecimal(value)
+	if err != nil || price.IsNegative() {
+		return nil, false
+	}
+	return &price, true
+}
+
+// parseCurrencyQuery parses the optional currency to convert prices into.
+// A missing parameter yields an empty currency.
+func parseCurrencyQuery(c *gin.Context) (string, bool) {
+	currency := repo.NormalizeIdentifier(c.Query("currency"))
+	if currency == "" {
+		return "", true
+	}
+	return currency, repo.IsCurrencyCode(currency)
+}
@@ -0,0 +1,156 @@
+package stock_handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"stock-api/repo"
+	"stock-api/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxNameLength bounds the name of a stock.
+const maxNameLength = 100
+
+// maxClockSkew tolerates clients whose clock is slightly ahead when checking lastUpdate.
+const maxClockSkew = time.Minute
+
+// StockRequest is the stock sent by clients to create, replace or patch a stock.
+// The ID, version and deletion of a stock are managed by the API.
+type StockRequest struct {
+	Name         string       `json:"name" example:"Apple Inc."`
+	Symbol       string       `json:"symbol" example:"AAPL"`
+	Exchange     string       `json:"exchange" example:"NASDAQ"`
+	Currency     string       `json:"currency" example:"USD"`
+	ISIN         string       `json:"isin" example:"US0378331005"`
+	CurrentPrice util.Decimal `json:"currentPrice" swaggertype:"number" example:"100.5"`
+	// LastUpdate defaults to now
+	LastUpdate time.Time `json:"lastUpdate"`
+}
+
+// newStockRequest returns the request holding the current fields of a stock.
+func newStockRequest(stock *repo.Stock) StockRequest {
+	return StockRequest{
+		Name:         stock.Name,
+		Symbol:       stock.Symbol,
+		Exchange:     stock.Exchange,
+		Currency:     stock.Currency,
+		ISIN:         stock.ISIN,
+		CurrentPrice: stock.CurrentPrice,
+		LastUpdate:   stock.LastUpdate,
+	}
+}
+
+// normalize trims the fields of the request, upper cases its identifiers and
+// converts lastUpdate to UTC, as SQLite compares times as text.
+func (r *StockRequest) normalize(now time.Time) {
+	r.Name = strings.TrimSpace(r.Name)
+	r.Symbol = repo.NormalizeIdentifier(r.Symbol)
+	r.Exchange = repo.NormalizeIdentifier(r.Exchange)
+	r.Currency = repo.NormalizeIdentifier(r.Currency)
+	r.ISIN = repo.NormalizeIdentifier(r.ISIN)
+	if r.LastUpdate.IsZero() {
+		r.LastUpdate = now
+	}
+	r.LastUpdate = r.LastUpdate.UTC()
+}
+
+// validate adds the invalid fields of a normalized request to errs. Symbol and exchange
+// are required, unless they are missing from the current version of the stock.
+func (r *StockRequest) validate(current *repo.Stock, now time.Time, errs *util.FieldErrors) {
+	if !errs.Has("name") {
+		switch {
+		case r.Name == "":
+			errs.Add("name", "is required")
+		case !util.ValidateStr(r.Name):
+			errs.Add("name", "must contain a letter or a digit")
+		case utf8.RuneCountInString(r.Name) > maxNameLength:
+			errs.Add("name", fmt.Sprintf("must be at most %d characters", maxNameLength))
+		}
+	}
+	if !errs.Has("symbol") && r.Symbol == "" && (current == nil || current.Symbol != "") {
+		errs.Add("symbol", "is required")
+	}
+	if !errs.Has("exchange") && r.Exchange == "" && (current == nil || current.Exchange != "") {
+		errs.Add("exchange", "is required")
+	}
+	if !errs.Has("currency") && r.Currency != "" && !repo.IsCurrencyCode(r.Currency) {
+		errs.Add("currency", "must be an ISO 4217 code")
+	}
+	if !errs.Has("currentPrice") && (r.CurrentPrice.IsNegative() || r.CurrentPrice.IsZero()) {
+		errs.Add("currentPrice", "must be greater than 0")
+	}
+	if !errs.Has("lastUpdate") && r.LastUpdate.After(now.Add(maxClockSkew)) {
+		errs.Add("lastUpdate", "must not be in the future")
+	}
+}
+
+// apply returns the stock with the fields of the request.
+func (r *StockRequest) apply(stock repo.Stock) repo.Stock {
+	stock.Name = r.Name
+	stock.Symbol = r.Symbol
+	stock.Exchange = r.Exchange
+	stock.Currency = r.Currency
+	stock.ISIN = r.ISIN
+	stock.CurrentPrice = r.CurrentPrice
+	stock.LastUpdate = r.LastUpdate
+	return stock
+}
+
+// bindStockRequest decodes, normalizes and validates the stock of a request body,
+// current being the stock it replaces, if any. It writes the error response and
+// returns false when the stock is invalid.
+func bindStockRequest(c *gin.Context, data []byte, current *repo.Stock) (StockRequest, bool) {
+	var req StockRequest
+	errs, err := util.DecodeJSONFields(data, &req)
+	if err != nil {
+		util.AbortWithProblem(c, util.ProblemMalformedJSON, "Invalid JSON body, expected a stock object")
+		return req, false
+	}
+
+	now := time.Now().UTC()
+	req.normalize(now)
+	req.validate(current, now, &errs)
+	if len(errs) > 0 {
+		util.AbortWithError(c, &util.ProblemError{Type: util.ProblemValidation, Detail: "Invalid stock", Fields: errs})
+		return req, false
+	}
+	return req, true
+}
+
+// patchStockRequest applies a JSON merge patch to the fields of the current stock,
+// then binds them like bindStockRequest. A patch changing the price without
+// a lastUpdate updates it now, the new price tick being recorded at lastUpdate.
+func patchStockRequest(c *gin.Context, patch []byte, current *repo.Stock) (StockRequest, bool) {
+	doc, err := json.Marshal(newStockRequest(current))
+	if err != nil {
+		util.AbortWithError(c, err)
+		return StockRequest{}, false
+	}
+
+	patched, err := util.MergePatch(doc, patch)
+	if err != nil {
+		util.AbortWithProblem(c, util.ProblemBadRequest, "Invalid merge patch, expected a JSON object")
+		return StockRequest{}, false
+	}
+
+	req, ok := bindStockRequest(c, patched, current)
+	if ok && !req.CurrentPrice.Equal(current.CurrentPrice) && !patchSets(patch, "lastUpdate") {
+		req.LastUpdate = time.Now().UTC()
+	}
+	return req, ok
+}
+
+// patchSets reports whether a merge patch object has a member for field.
+func patchSets(patch []byte, field string) bool {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &members); err != nil {
+		return false
+	}
+	_, ok := members[field]
+	return ok
+}
@@ -1,11 +1,30 @@
 package stock_handler
 
-import "github.com/gin-gonic/gin"
-
-func RegisterRoutes(router *gin.Engine) {
-	router.GET("/api/stocks", GetStocks)
-	router.POST("/api/stocks", CreateStock)
-	router.GET("/api/stocks/:id", GetStockByID)
-	router.PATCH("/api/stocks/:id", UpdateStock)
-	router.DELETE("/api/stocks/:id", DeleteStock)
+import (
+	"net/http"
+
+	"stock-api/api-portal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// routes is the permission table of the stock endpoints.
+func (h *StockHandler) routes() []middleware.Route {
+	return []middleware.Route{
+		{Method: http.MethodGet, Path: "/api/stocks", Handler: h.GetStocks, Roles: middleware.ReadRoles},
+		{Method: http.MethodPost, Path: "/api/stocks", Handler: h.CreateStock, Roles: middleware.WriteRoles},
+		{Method: http.MethodGet, Path: "/api/stocks/search", Handler: h.SearchStocks, Roles: middleware.ReadRoles},
+		{Method: http.MethodGet, Path: "/api/stocks/by-symbol/:exchange/:symbol", Handler: h.GetStockBySymbol, Roles: middleware.ReadRoles},
+		{Method: http.MethodGet, Path: "/api/stocks/:id", Handler: h.GetStockByID, Roles: middleware.ReadRoles},
+		{Method: http.MethodPatch, Path: "/api/stocks/:id", Handler: h.UpdateStock, Roles: middleware.WriteRoles},
+		{Method: http.MethodPut, Path: "/api/stocks/:id", Handler: h.ReplaceStock, Roles: middleware.WriteRoles},
+		{Method: http.MethodDelete, Path: "/api/stocks/:id", Handler: h.DeleteStock, Roles: middleware.WriteRoles},
+		{Method: http.MethodPost, Path: "/api/stocks/:id/restore", Handler: h.RestoreStock, Roles: middleware.WriteRoles},
+		{Method: http.MethodGet, Path: "/api/stocks/:id/prices", Handler: h.GetStockPrices, Roles: middleware.ReadRoles},
+		{Method: http.MethodGet, Path: "/api/stocks/:id/candles", Handler: h.GetStockCandles, Roles: middleware.ReadRoles},
+	}
+}
+
+func RegisterRoutes(router gin.IRouter, h *StockHandler) {
+	middleware.RegisterRoutes(router, h.routes())
 }
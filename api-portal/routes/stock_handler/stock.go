@@ -1,9 +1,14 @@
 package stock_handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 
+	"stock-api/api-portal/middleware"
 	"stock-api/repo"
 	"stock-api/util"
 
@@ -15,166 +20,637 @@ import (
 // @version 1
 // @host localhost:8080
 // @BasePath /api
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description Access token from /auth/token, prefixed with "Bearer ".
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @description API key minted through /api-keys.
+
+// maxSearchLength bounds the search text, longer texts only cost more to rank.
+const maxSearchLength = 100
+
+const stockModifiedMessage = "Stock was modified since, get its new version and retry"
+
+// mimeMergePatch is the content type of RFC 7396 JSON merge patches.
+const mimeMergePatch = "application/merge-patch+json"
+
+// StockHandler serves the stock endpoints from a StockRepository,
+// converting prices with the rates of an FxRateRepository.
+type StockHandler struct {
+	Repo      repo.StockRepository
+	Converter *repo.FxConverter
+}
+
+// NewStockHandler initializes a new StockHandler with its repositories.
+func NewStockHandler(stockRepo repo.StockRepository, fxRateRepo repo.FxRateRepository) *StockHandler {
+	return &StockHandler{Repo: stockRepo, Converter: repo.NewFxConverter(fxRateRepo)}
+}
 
 // @Summary Get a list of stocks
-// @Description Retrieves a list of stocks with pagination.
+// @Description Retrieves a page of stocks along with the total count, and first, prev, next and last links in the Link header.
+// @Description Passing cursor or limit walks the stocks by cursor instead, the response then holds data and nextCursor,
+// @Description and the Link header only a next link.
+// @Description Price filters and sort apply to the prices in the currency of each stock, before any conversion.
 // @Accept json
 // @Produce json
+// @Param cursor query string false "Cursor of the next page, from nextCursor"
+// @Param limit query int false "Number of stocks per page in cursor mode (default is 10, at most 100)"
 // @Param page query int false "Page number (default is 1)"
-// @Param pageSize query int false "Number of stocks per page (default is 10)"
-// @Success 200 {array} repo.Stock
-// @Failure 400 {object} util.ErrorResponse
-// @Failure 500 {object} util.ErrorResponse
+// @Param pageSize query int false "Number of stocks per page (default is 10, at most 100)"
+// @Param name query string false "Keep the stocks whose name contains it, ignoring case"
+// @Param minPrice query number false "Keep the stocks priced at least minPrice"
+// @Param maxPrice query number false "Keep the stocks priced at most maxPrice"
+// @Param updatedSince query string false "Keep the stocks updated since, RFC 3339"
+// @Param sort query string false "Comma separated id, name, currentPrice or lastUpdate, prefixed with - to sort descending, e.g. name,-currentPrice. Not available with a cursor"
+// @Param currency query string false "Convert the prices into this ISO 4217 currency, e.g. USD"
+// @Param includeDeleted query bool false "Include the deleted stocks that aren't purged yet, admins only"
+// @Success 200 {object} util.PageResponse{data=[]repo.Stock}
+// @Header 200 {string} Link "RFC 8288 links to the other pages"
+// @Failure 400 {object} util.Problem
+// @Failure 401 {object} util.Problem
+// @Failure 403 {object} util.Problem
+// @Failure 500 {object} util.Problem
+// @Failure 503 {object} util.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /stocks [get]
-func GetStocks(c *gin.Context) {
+func (h *StockHandler) GetStocks(c *gin.Context) {
+	query, message := parseStockQuery(c)
+	if message != "" {
+		util.AbortWithProblem(c, util.ProblemBadRequest, message)
+		return
+	}
+
+	if identity, ok := middleware.GetIdentity(c); query.IncludeDeleted && (!ok || !identity.HasRole(middleware.AdminRoles...)) {
+		util.AbortWithProblem(c, util.ProblemForbidden, "Only admins can include deleted stocks")
+		return
+	}
+
+	currency, ok := parseCurrencyQuery(c)
+	if !ok {
+		util.AbortWithProblem(c, util.ProblemBadRequest, invalidCurrencyMessage)
+		return
+	}
+
+	if isCursorQuery(c) {
+		h.getStocksByCursor(c, query, currency)
+		return
+	}
+
 	// Get query parameters for pagination
 	page := c.DefaultQuery("page", "1")
 	pageSize := c.DefaultQuery("pageSize", "10")
 
 	// Convert query parameters to integers
 	pageInt, err := strconv.Atoi(page)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, util.BadRequestResponse)
+	if err != nil || pageInt < 1 {
+		util.AbortWithProblem(c, util.ProblemBadRequest, "Invalid page")
 		return
 	}
 
 	pageSizeInt, err := strconv.Atoi(pageSize)
+	if err != nil || pageSizeInt < 1 || pageSizeInt > maxPageSize {
+		util.AbortWithProblem(c, util.ProblemBadRequest, "Invalid page size")
+		return
+	}
+
+	// Retrieve the page of matching stocks from the repository
+	query.Offset = (pageInt - 1) * pageSizeInt
+	query.Limit = pageSizeInt
+	stocks, err := h.Repo.FindStocks(c.Request.Context(), query)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, util.BadRequestResponseCustom("Invalid page size"))
+		util.AbortWithError(c, err)
 		return
 	}
 
-	// Retrieve paginated stocks from the repository
-	stocks, err := repo.Server.StockRepo.GetPaginatedStocks(pageInt, pageSizeInt)
+	total, err := h.Repo.CountStocks(c.Request.Context(), query)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, util.InternalServerErrorResponse)
+		util.AbortWithError(c, err)
+		return
+	}
+
+	if !h.convertStocks(c, stocks, currency) {
 		return
 	}
 
-	c.JSON(http.StatusOK, stocks)
+	response := util.NewPageResponse(stocks, pageInt, pageSizeInt, total)
+	util.SetPageLinks(c, response)
+	c.JSON(http.StatusOK, response)
 }
 
 // @Summary Create a new stock
-// @Description Creates a new stock.
+// @Description Creates a new stock, its symbol must be unique on its exchange.
+// @Description Every invalid field is listed in a 422 response.
 // @Accept json
 // @Produce json
-// @Param stock body repo.Stock true "Stock object to create"
+// @Param stock body StockRequest true "Stock object to create"
 // @Success 201 {object} repo.Stock
-// @Failure 400 {object} util.ErrorResponse
-// @Failure 500 {object} util.ErrorResponse
+// @Header 201 {string} ETag "Version of the stock, to send in If-Match when updating it"
+// @Failure 400 {object} util.Problem
+// @Failure 401 {object} util.Problem
+// @Failure 403 {object} util.Problem
+// @Failure 409 {object} util.Problem
+// @Failure 422 {object} util.Problem
+// @Failure 500 {object} util.Problem
+// @Failure 503 {object} util.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /stocks [post]
-func CreateStock(c *gin.Context) {
-	var stock repo.Stock
-	if err := c.ShouldBindJSON(&stock); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+func (h *StockHandler) CreateStock(c *gin.Context) {
+	data, err := c.GetRawData()
+	if err != nil {
+		util.AbortWithProblem(c, util.ProblemBadRequest, "Can't read the request body")
+		return
+	}
+	req, ok := bindStockRequest(c, data, nil)
+	if !ok {
 		return
 	}
 
-	if err := repo.Server.StockRepo.CreateStock(&stock); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create stock"})
+	stock := req.apply(repo.Stock{})
+	if err := h.Repo.CreateStock(c.Request.Context(), &stock); err != nil {
+		util.AbortWithError(c, stockError(err))
 		return
 	}
 
+	setStockETag(c, &stock)
 	c.JSON(http.StatusCreated, stock)
 }
 
+// @Summary Search stocks
+// @Description Searches stocks by name, tolerating typos and partial names, best matches first.
+// @Accept json
+// @Produce json
+// @Param q query string true "Text to search, at most 100 characters"
+// @Param limit query int false "Maximum number of stocks (default is 10, at most 100)"
+// @Success 200 {array} repo.StockMatch
+// @Failure 400 {object} util.Problem
+// @Failure 401 {object} util.Problem
+// @Failure 403 {object} util.Problem
+// @Failure 500 {object} util.Problem
+// @Failure 503 {object} util.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /stocks/search [get]
+func (h *StockHandler) SearchStocks(c *gin.Context) {
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" || utf8.RuneCountInString(q) > maxSearchLength {
+		util.AbortWithProblem(c, util.ProblemBadRequest, "Invalid q")
+		return
+	}
+
+	limit, ok := parseLimitQuery(c)
+	if !ok {
+		util.AbortWithProblem(c, util.ProblemBadRequest, "Invalid limit")
+		return
+	}
+
+	matches, err := h.Repo.SearchStocks(c.Request.Context(), q, limit)
+	if err != nil {
+		util.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, matches)
+}
+
+// @Summary Get a stock by symbol
+// @Description Retrieves a single stock by its exchange and symbol, ignoring case.
+// @Accept json
+// @Produce json
+// @Param exchange path string true "Exchange, e.g. NASDAQ"
+// @Param symbol path string true "Symbol, e.g. AAPL"
+// @Param currency query string false "Convert the prices into this ISO 4217 currency, e.g. USD"
+// @Success 200 {object} repo.Stock
+// @Failure 400 {object} util.Problem
+// @Failure 401 {object} util.Problem
+// @Failure 403 {object} util.Problem
+// @Failure 404 {object} util.Problem
+// @Failure 500 {object} util.Problem
+// @Failure 503 {object} util.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /stocks/by-symbol/{exchange}/{symbol} [get]
+func (h *StockHandler) GetStockBySymbol(c *gin.Context) {
+	exchange := repo.NormalizeIdentifier(c.Param("exchange"))
+	symbol := repo.NormalizeIdentifier(c.Param("symbol"))
+
+	currency, ok := parseCurrencyQuery(c)
+	if !ok {
+		util.AbortWithProblem(c, util.ProblemBadRequest, invalidCurrencyMessage)
+		return
+	}
+
+	stock, err := h.Repo.GetStockBySymbol(c.Request.Context(), exchange, symbol)
+	if err != nil {
+		util.AbortWithError(c, stockError(err))
+		return
+	}
+
+	converted, ok := h.convertStock(c, *stock, currency)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, converted)
+}
+
 // @Summary Get a stock by ID
-// @Description Retrieves a single stock by its ID.
+// @Description Retrieves a single stock by its ID, and its version in the ETag header.
 // @Accept json
 // @Produce json
-// @Param id path int true "Stock ID"
+// @Param id path string true "Stock ID"
+// @Param currency query string false "Convert the prices into this ISO 4217 currency, e.g. USD"
 // @Success 200 {object} repo.Stock
-// @Failure 400 {object} util.ErrorResponse
-// @Failure 404 {object} util.ErrorResponse
-// @Failure 500 {object} util.ErrorResponse
+// @Header 200 {string} ETag "Version of the stock, to send in If-Match when updating it, weak when converted into another currency"
+// @Failure 400 {object} util.Problem
+// @Failure 401 {object} util.Problem
+// @Failure 403 {object} util.Problem
+// @Failure 404 {object} util.Problem
+// @Failure 500 {object} util.Problem
+// @Failure 503 {object} util.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /stocks/{id} [get]
-func GetStockByID(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
+func (h *StockHandler) GetStockByID(c *gin.Context) {
+	id, err := util.DecodeID(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid stock ID"})
+		util.AbortWithProblem(c, util.ProblemBadRequest, "Invalid stock ID")
 		return
 	}
 
-	stock, err := repo.Server.StockRepo.GetStockByID(uint(id))
+	currency, ok := parseCurrencyQuery(c)
+	if !ok {
+		util.AbortWithProblem(c, util.ProblemBadRequest, invalidCurrencyMessage)
+		return
+	}
+
+	stock, err := h.Repo.GetStockByID(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Stock not found"})
+		util.AbortWithError(c, stockError(err))
+		return
+	}
+
+	converted, ok := h.convertStock(c, *stock, currency)
+	if !ok {
 		return
 	}
 
-	c.JSON(http.StatusOK, *stock)
+	setConvertedStockETag(c, stock, &converted)
+	c.JSON(http.StatusOK, converted)
 }
 
-// @Summary Update a stock's price
-// @Description Updates the price of a single stock.
-// @Accept json
+// @Summary Update a stock
+// @Description Applies an RFC 7396 JSON merge patch to a single stock: the given fields replace the current ones,
+// @Description null fields are reset, and omitted fields keep their current value.
+// @Description lastUpdate defaults to now when the patch changes currentPrice without it.
+// @Description If-Match must hold the ETag of the stock, the update fails with 412 when it was modified since.
+// @Accept application/merge-patch+json,json
 // @Produce json
-// @Param id path int true "Stock ID"
-// @Param updatedStock body repo.Stock true "Updated Stock object"
+// @Param id path string true "Stock ID"
+// @Param If-Match header string true "ETag of the stock being updated"
+// @Param patch body StockRequest true "Fields to change"
 // @Success 200 {object} repo.Stock
-// @Failure 400 {object} util.ErrorResponse
-// @Failure 404 {object} util.ErrorResponse
-// @Failure 500 {object} util.ErrorResponse
+// @Header 200 {string} ETag "New version of the stock"
+// @Failure 400 {object} util.Problem
+// @Failure 401 {object} util.Problem
+// @Failure 403 {object} util.Problem
+// @Failure 404 {object} util.Problem
+// @Failure 409 {object} util.Problem
+// @Failure 412 {object} util.Problem
+// @Failure 415 {object} util.Problem
+// @Failure 422 {object} util.Problem
+// @Failure 428 {object} util.Problem
+// @Failure 500 {object} util.Problem
+// @Failure 503 {object} util.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /stocks/{id} [patch]
-func UpdateStock(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
+func (h *StockHandler) UpdateStock(c *gin.Context) {
+	switch c.ContentType() {
+	case "", gin.MIMEJSON, mimeMergePatch:
+	default:
+		util.AbortWithProblem(c, util.ProblemUnsupportedMediaType, "Unsupported content type, expected "+mimeMergePatch)
+		return
+	}
+
+	h.updateStock(c, patchStockRequest)
+}
+
+// @Summary Replace a stock
+// @Description Replaces every field of a single stock, omitted fields are reset. Symbol and exchange are required.
+// @Description If-Match must hold the ETag of the stock, the update fails with 412 when it was modified since.
+// @Accept json
+// @Produce json
+// @Param id path string true "Stock ID"
+// @Param If-Match header string true "ETag of the stock being replaced"
+// @Param stock body StockRequest true "New version of the stock"
+// @Success 200 {object} repo.Stock
+// @Header 200 {string} ETag "New version of the stock"
+// @Failure 400 {object} util.Problem
+// @Failure 401 {object} util.Problem
+// @Failure 403 {object} util.Problem
+// @Failure 404 {object} util.Problem
+// @Failure 409 {object} util.Problem
+// @Failure 412 {object} util.Problem
+// @Failure 422 {object} util.Problem
+// @Failure 428 {object} util.Problem
+// @Failure 500 {object} util.Problem
+// @Failure 503 {object} util.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /stocks/{id} [put]
+func (h *StockHandler) ReplaceStock(c *gin.Context) {
+	h.updateStock(c, bindStockRequest)
+}
+
+// updateStock serves the updates of a stock: it checks the If-Match precondition
+// against the current version of the stock, then saves the stock that bind reads
+// from the request body. bind writes the error response and returns false when it is invalid.
+func (h *StockHandler) updateStock(c *gin.Context, bind func(c *gin.Context, data []byte, current *repo.Stock) (StockRequest, bool)) {
+	id, err := util.DecodeID(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid stock ID"})
+		util.AbortWithProblem(c, util.ProblemBadRequest, "Invalid stock ID")
 		return
 	}
 
-	var updatedStock repo.Stock
-	if err := c.ShouldBindJSON(&updatedStock); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	ifMatch := c.GetHeader(headerIfMatch)
+	if ifMatch == "" {
+		util.AbortWithProblem(c, util.ProblemPreconditionRequired, "If-Match is required, send the ETag of the stock")
 		return
 	}
 
-	// Check if the stock with the given ID exists
-	_, err = repo.Server.StockRepo.GetStockByID(uint(id))
+	current, err := h.Repo.GetStockByID(c.Request.Context(), id)
+	if err != nil {
+		util.AbortWithError(c, stockError(err))
+		return
+	}
+
+	if !matchesIfMatch(ifMatch, current) {
+		util.AbortWithProblem(c, util.ProblemPreconditionFailed, stockModifiedMessage)
+		return
+	}
+
+	data, err := c.GetRawData()
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Stock not found"})
+		util.AbortWithProblem(c, util.ProblemBadRequest, "Can't read the request body")
+		return
+	}
+	req, ok := bind(c, data, current)
+	if !ok {
 		return
 	}
 
-	updatedStock.ID = uint(id)
+	// the repository only updates the version that matched
+	updatedStock := req.apply(repo.Stock{ID: util.PublicID(id), Version: current.Version})
 
-	if err := repo.Server.StockRepo.UpdateStock(&updatedStock); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update stock"})
+	if err := h.Repo.UpdateStock(c.Request.Context(), &updatedStock); err != nil {
+		util.AbortWithError(c, stockError(err))
 		return
 	}
 
+	setStockETag(c, &updatedStock)
 	c.JSON(http.StatusOK, updatedStock)
 }
 
 // @Summary Delete a stock by ID
-// @Description Deletes a single stock by its ID.
+// @Description Deletes a single stock by its ID. It can be restored until it is purged after the retention period.
 // @Accept json
 // @Produce json
-// @Param id path int true "Stock ID"
-// @Success 200 {object} util.Response
-// @Failure 400 {object} util.ErrorResponse
-// @Failure 404 {object} util.ErrorResponse
-// @Failure 500 {object} util.ErrorResponse
+// @Param id path string true "Stock ID"
+// @Success 200 {object} util.MessageResponse
+// @Failure 400 {object} util.Problem
+// @Failure 401 {object} util.Problem
+// @Failure 403 {object} util.Problem
+// @Failure 404 {object} util.Problem
+// @Failure 500 {object} util.Problem
+// @Failure 503 {object} util.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /stocks/{id} [delete]
-func DeleteStock(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
+func (h *StockHandler) DeleteStock(c *gin.Context) {
+	id, err := util.DecodeID(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid stock ID"})
+		util.AbortWithProblem(c, util.ProblemBadRequest, "Invalid stock ID")
 		return
 	}
 
 	// Check if the stock with the given ID exists
-	_, err = repo.Server.StockRepo.GetStockByID(uint(id))
+	_, err = h.Repo.GetStockByID(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Stock not found"})
+		util.AbortWithError(c, stockError(err))
 		return
 	}
 
-	if err := repo.Server.StockRepo.DeleteStock(uint(id)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete stock"})
+	if err := h.Repo.DeleteStock(c.Request.Context(), id); err != nil {
+		util.AbortWithError(c, stockError(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Stock deleted successfully",
-	})
+	c.JSON(http.StatusOK, util.MessageResponse{Message: "Stock deleted successfully"})
+}
+
+// @Summary Restore a deleted stock
+// @Description Restores a single stock deleted less than the retention period ago, along with its price history.
+// @Accept json
+// @Produce json
+// @Param id path string true "Stock ID"
+// @Success 200 {object} repo.Stock
+// @Failure 400 {object} util.Problem
+// @Failure 401 {object} util.Problem
+// @Failure 403 {object} util.Problem
+// @Failure 404 {object} util.Problem
+// @Failure 409 {object} util.Problem
+// @Failure 500 {object} util.Problem
+// @Failure 503 {object} util.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /stocks/{id}/restore [post]
+func (h *StockHandler) RestoreStock(c *gin.Context) {
+	id, err := util.DecodeID(c.Param("id"))
+	if err != nil {
+		util.AbortWithProblem(c, util.ProblemBadRequest, "Invalid stock ID")
+		return
+	}
+
+	stock, err := h.Repo.RestoreStock(c.Request.Context(), id)
+	if errors.Is(err, repo.ErrStockNotFound) {
+		util.AbortWithError(c, util.WithDetail(err, "Deleted stock not found"))
+		return
+	}
+	if err != nil {
+		util.AbortWithError(c, stockError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, stock)
+}
+
+// @Summary Get the price history of a stock
+// @Description Retrieves the recorded prices of a single stock, optionally limited to a time range.
+// @Accept json
+// @Produce json
+// @Param id path string true "Stock ID"
+// @Param from query string false "Start of the range (RFC 3339)"
+// @Param to query string false "End of the range (RFC 3339)"
+// @Success 200 {array} repo.StockPrice
+// @Failure 400 {object} util.Problem
+// @Failure 401 {object} util.Problem
+// @Failure 403 {object} util.Problem
+// @Failure 404 {object} util.Problem
+// @Failure 500 {object} util.Problem
+// @Failure 503 {object} util.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /stocks/{id}/prices [get]
+func (h *StockHandler) GetStockPrices(c *gin.Context) {
+	id, err := util.DecodeID(c.Param("id"))
+	if err != nil {
+		util.AbortWithProblem(c, util.ProblemBadRequest, "Invalid stock ID")
+		return
+	}
+
+	from, err := parseTimeQuery(c, "from")
+	if err != nil {
+		util.AbortWithProblem(c, util.ProblemBadRequest, "Invalid from time")
+		return
+	}
+
+	to, err := parseTimeQuery(c, "to")
+	if err != nil {
+		util.AbortWithProblem(c, util.ProblemBadRequest, "Invalid to time")
+		return
+	}
+
+	// Check if the stock with the given ID exists
+	_, err = h.Repo.GetStockByID(c.Request.Context(), id)
+	if err != nil {
+		util.AbortWithError(c, stockError(err))
+		return
+	}
+
+	prices, err := h.Repo.GetStockPrices(c.Request.Context(), id, from, to)
+	if err != nil {
+		util.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, prices)
+}
+
+// @Summary Get the price candles of a stock
+// @Description Aggregates the recorded prices of a single stock into open/high/low/close candles.
+// @Accept json
+// @Produce json
+// @Param id path string true "Stock ID"
+// @Param interval query string false "Candle interval: 1m, 5m, 1h or 1d (default is 1h)"
+// @Param from query string false "Start of the range (RFC 3339)"
+// @Param to query string false "End of the range (RFC 3339)"
+// @Success 200 {array} repo.Candle
+// @Failure 400 {object} util.Problem
+// @Failure 401 {object} util.Problem
+// @Failure 403 {object} util.Problem
+// @Failure 404 {object} util.Problem
+// @Failure 500 {object} util.Problem
+// @Failure 503 {object} util.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /stocks/{id}/candles [get]
+func (h *StockHandler) GetStockCandles(c *gin.Context) {
+	id, err := util.DecodeID(c.Param("id"))
+	if err != nil {
+		util.AbortWithProblem(c, util.ProblemBadRequest, "Invalid stock ID")
+		return
+	}
+
+	interval, err := repo.ParseCandleInterval(c.DefaultQuery("interval", string(repo.CandleInterval1h)))
+	if err != nil {
+		util.AbortWithProblem(c, util.ProblemBadRequest, "Invalid interval")
+		return
+	}
+
+	from, err := parseTimeQuery(c, "from")
+	if err != nil {
+		util.AbortWithProblem(c, util.ProblemBadRequest, "Invalid from time")
+		return
+	}
+
+	to, err := parseTimeQuery(c, "to")
+	if err != nil {
+		util.AbortWithProblem(c, util.ProblemBadRequest, "Invalid to time")
+		return
+	}
+
+	// Check if the stock with the given ID exists
+	_, err = h.Repo.GetStockByID(c.Request.Context(), id)
+	if err != nil {
+		util.AbortWithError(c, stockError(err))
+		return
+	}
+
+	candles, err := h.Repo.GetStockCandles(c.Request.Context(), id, interval, from, to)
+	if err != nil {
+		util.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, candles)
+}
+
+// convertStocks converts the prices of the stocks into currency, unless it is empty.
+// It writes the error response and returns false when they can't be converted.
+func (h *StockHandler) convertStocks(c *gin.Context, stocks []repo.Stock, currency string) bool {
+	if currency == "" {
+		return true
+	}
+
+	if err := h.Converter.ConvertStocks(c.Request.Context(), stocks, currency); err != nil {
+		util.AbortWithError(c, stockError(err))
+		return false
+	}
+	return true
+}
+
+// convertStock returns a copy of a single stock converted like convertStocks.
+func (h *StockHandler) convertStock(c *gin.Context, stock repo.Stock, currency string) (repo.Stock, bool) {
+	stocks := []repo.Stock{stock}
+	if !h.convertStocks(c, stocks, currency) {
+		return stock, false
+	}
+	return stocks[0], true
+}
+
+// stockError adds the detail reported to clients to an error of the stock
+// or exchange rate repositories. The Problems middleware maps it to its status.
+func stockError(err error) error {
+	switch {
+	case errors.Is(err, repo.ErrStockNotFound):
+		return util.WithDetail(err, "Stock not found")
+	case errors.Is(err, repo.ErrConflict):
+		return util.WithDetail(err, "A stock with this symbol already exists on this exchange")
+	case errors.Is(err, repo.ErrUnavailable):
+		return util.WithDetail(err, "The stock database is unavailable, retry later")
+	case errors.Is(err, repo.ErrVersionConflict):
+		return util.WithDetail(err, stockModifiedMessage)
+	case errors.Is(err, repo.ErrFxRateNotFound):
+		return util.WithDetail(err, err.Error())
+	}
+	return err
+}
+
+// parseTimeQuery parses an optional RFC 3339 query parameter into UTC, as SQLite
+// compares times as text. A missing parameter yields the zero time.
+func parseTimeQuery(c *gin.Context, key string) (time.Time, error) {
+	value := c.Query(key)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.UTC(), nil
 }
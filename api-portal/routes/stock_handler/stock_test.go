@@ -2,129 +2,75 @@ package stock_handler
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
-	"strconv"
+	"net/url"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"stock-api/api-portal/middleware"
 	"stock-api/repo"
 	"stock-api/util"
 
-	"github.com/stretchr/testify/mock"
-
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm/logger"
 )
 
-// DB interface defines the methods for interacting with the database.
-type DB interface {
-	GetPaginatedStocks(page, pageSize int) ([]repo.Stock, error)
-}
-type MockDB struct {
-	mock.Mock
-}
-
-func (m *MockDB) GetPaginatedStocks(page, pageSize int) ([]repo.Stock, error) {
-	// return predefined data
-	if page == 2 && pageSize == 20 {
-		return TempStockList, nil
-	}
-	return nil, errors.New("Error retrieving stocks")
-}
-
-func (m *MockDB) CreateStock(stock *repo.Stock) error {
-	if stock.ID > 0 {
-		return nil
-	}
-	return errors.New("Error creating stock")
-}
-
-func (m *MockDB) GetStockByID(id uint) (*repo.Stock, error) {
-	if id == 1 {
-		return &TempStockList[0], nil
-	}
-	return nil, errors.New("Error retrieving stock")
-}
-
-func (m *MockDB) UpdateStock(stock *repo.Stock) error {
-	if stock.ID > 0 {
-		return nil
-	}
-	return errors.New("Error updating stock")
-}
-
-func (m *MockDB) DeleteStock(id uint) error {
-	if id > 100 {
-		return errors.New("Stock not found")
-	}
-	if id > 0 {
-		return nil
-	}
-	return errors.New("Error deleting stock")
-}
-
 var (
 	now           = time.Now()
 	TempStockList = []repo.Stock{
 		{
 			ID:           1,
 			Name:         "Apple",
-			CurrentPrice: 10.0,
+			CurrentPrice: util.MustParseDecimal("10.0"),
 			LastUpdate:   now,
 		},
 		{
 			ID:           2,
 			Name:         "Google",
-			CurrentPrice: 20.0,
+			CurrentPrice: util.MustParseDecimal("20.0"),
 			LastUpdate:   now,
 		},
 		{
 			ID:           3,
 			Name:         "Microsoft",
-			CurrentPrice: 30.0,
+			CurrentPrice: util.MustParseDecimal("30.0"),
 			LastUpdate:   now,
 		},
 	}
 	tempStockLen = len(TempStockList)
-	format = "2006-01-02 15:04:05"
+	format       = "2006-01-02 15:04:05"
 )
-func GetStocksWithMockDB(c *gin.Context) {
-	// Get query parameters for pagination
-	page := c.DefaultQuery("page", "1")
-	pageSize := c.DefaultQuery("pageSize", "10")
-
-	// Convert query parameters to integers
-	pageInt, err := strconv.Atoi(page)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, util.BadRequestResponse)
-		return
-	}
 
-	pageSizeInt, err := strconv.Atoi(pageSize)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, util.BadRequestResponseCustom("Invalid page size"))
-		return
-	}
-
-	// Retrieve paginated stocks from the repository
-	m := new(MockDB)
-	stocks, err := m.GetPaginatedStocks(pageInt, pageSizeInt)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, util.InternalServerErrorResponse)
-		return
-	}
+// newRouter creates a router writing the errors of the handlers as problems.
+func newRouter() *gin.Engine {
+	r := gin.New()
+	r.Use(middleware.Problems())
+	return r
+}
 
-	c.JSON(http.StatusOK, stocks)
+// newMockHandler creates a StockHandler backed by a mock repository.
+func newMockHandler() (*StockHandler, *repo.MockStockRepo) {
+	mockDB := new(repo.MockStockRepo)
+	return NewStockHandler(mockDB, repo.NewMemoryFxRateRepo()), mockDB
 }
 
 func TestGetStocks(t *testing.T) {
-	// Create a Gin router with the handler function
-	r := gin.Default()
+	// Create a mock repository returning the predefined data
+	h, mockDB := newMockHandler()
+	mockDB.On("FindStocks", mock.Anything, repo.StockQuery{Offset: 20, Limit: 20}).Return(TempStockList, nil)
+	mockDB.On("CountStocks", mock.Anything, repo.StockQuery{Offset: 20, Limit: 20}).Return(int64(63), nil)
 
-	r.GET("/api/stocks", GetStocksWithMockDB)
+	// Create a Gin router with the handler function
+	r := newRouter()
+	r.GET("/api/stocks", h.GetStocks)
 
 	// Create a mock HTTP request with query parameters
 	req, err := http.NewRequest("GET", "/api/stocks?page=2&pageSize=20", nil)
@@ -140,69 +86,220 @@ func TestGetStocks(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 
 	// Parse the JSON response body
-	var response []repo.Stock // Replace with the actual stock struct type
+	var response struct {
+		Data       []repo.Stock `json:"data"`
+		Page       int          `json:"page"`
+		PageSize   int          `json:"pageSize"`
+		Total      int64        `json:"total"`
+		TotalPages int          `json:"totalPages"`
+	}
 	err = json.Unmarshal(w.Body.Bytes(), &response)
 	assert.NoError(t, err)
 
 	// Perform assertions on the response data
-	assert.Len(t, response, tempStockLen)
+	assert.Len(t, response.Data, tempStockLen)
+	assert.Equal(t, 2, response.Page)
+	assert.Equal(t, 20, response.PageSize)
+	assert.Equal(t, int64(63), response.Total)
+	assert.Equal(t, 4, response.TotalPages)
+
+	// Check the links to the other pages
+	assert.Equal(t, `</api/stocks?page=1&pageSize=20>; rel="first", `+
+		`</api/stocks?page=1&pageSize=20>; rel="prev", `+
+		`</api/stocks?page=3&pageSize=20>; rel="next", `+
+		`</api/stocks?page=4&pageSize=20>; rel="last"`, w.Header().Get("Link"))
+	mockDB.AssertExpectations(t)
 }
 
-func TestGetStocksBadRequest(t *testing.T) {
+func TestGetStocksFiltered(t *testing.T) {
+	// Create a mock repository expecting the parsed query
+	minPrice, maxPrice := util.MustParseDecimal("10"), util.MustParseDecimal("25.5")
+	updatedSince := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	query := repo.StockQuery{
+		Name:         "app",
+		MinPrice:     &minPrice,
+		MaxPrice:     &maxPrice,
+		UpdatedSince: updatedSince,
+		Sort:         []repo.StockSort{{Field: "name"}, {Field: "currentPrice", Desc: true}},
+		Limit:        10,
+	}
+	h, mockDB := newMockHandler()
+	mockDB.On("FindStocks", mock.Anything, query).Return(TempStockList[:1], nil)
+	mockDB.On("CountStocks", mock.Anything, query).Return(int64(1), nil)
 
-	// Create a Gin router with the handler function
-	r := gin.Default()
-	r.GET("/api/stocks", GetStocks)
+	r := newRouter()
+	r.GET("/api/stocks", h.GetStocks)
 
-	// Create a mock HTTP request with invalid query parameters
-	req, err := http.NewRequest("GET", "/api/stocks?page=invalid&pageSize=20", nil)
+	req, err := http.NewRequest("GET", "/api/stocks?name=app&minPrice=10&maxPrice=25.5&updatedSince=2024-01-02T03:04:05Z&sort=name,-currentPrice", nil)
 	assert.NoError(t, err)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
 
-	// Create a mock HTTP response recorder
+	// The links keep the filters
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Link"), "name=app&page=1")
+	mockDB.AssertExpectations(t)
+}
+
+func TestGetStocksEmpty(t *testing.T) {
+	// Create a mock repository without stocks
+	h, mockDB := newMockHandler()
+	mockDB.On("FindStocks", mock.Anything, repo.StockQuery{Limit: 10}).Return([]repo.Stock{}, nil)
+	mockDB.On("CountStocks", mock.Anything, repo.StockQuery{Limit: 10}).Return(int64(0), nil)
+
+	r := newRouter()
+	r.GET("/api/stocks", h.GetStocks)
+
+	req, err := http.NewRequest("GET", "/api/stocks", nil)
+	assert.NoError(t, err)
 	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
 
-	// Serve the request to the Gin router
+	// An empty list has no page but the first
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"data":[],"page":1,"pageSize":10,"total":0,"totalPages":0}`, w.Body.String())
+	assert.Equal(t, `</api/stocks?page=1>; rel="first"`, w.Header().Get("Link"))
+}
+
+func TestGetStocksBadRequest(t *testing.T) {
+	h, mockDB := newMockHandler()
+
+	// Create a Gin router with the handler function
+	r := newRouter()
+	r.GET("/api/stocks", h.GetStocks)
+
+	for _, query := range []string{
+		"page=invalid&pageSize=20", "page=0", "page=-1", "pageSize=0", "pageSize=101", "pageSize=100000",
+		"minPrice=cheap", "maxPrice=-1", "minPrice=20&maxPrice=10", "updatedSince=yesterday", "sort=secret", "sort=name,-",
+	} {
+		// Create a mock HTTP request with invalid query parameters
+		req, err := http.NewRequest("GET", "/api/stocks?"+query, nil)
+		assert.NoError(t, err)
+
+		// Create a mock HTTP response recorder
+		w := httptest.NewRecorder()
+
+		// Serve the request to the Gin router
+		r.ServeHTTP(w, req)
+
+		// Check the HTTP response status code for a bad request
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+
+	// The repository is never reached
+	mockDB.AssertNotCalled(t, "FindStocks", mock.Anything, mock.Anything)
+}
+
+func TestGetStocksByCursor(t *testing.T) {
+	// Create a mock repository returning one stock more than the limit
+	h, mockDB := newMockHandler()
+	mockDB.On("FindStocks", mock.Anything, repo.StockQuery{Limit: 3}).Return(TempStockList, nil)
+	mockDB.On("FindStocks", mock.Anything, repo.StockQuery{AfterID: 2, Limit: 3}).Return(TempStockList[2:], nil)
+
+	r := newRouter()
+	r.GET("/api/stocks", h.GetStocks)
+
+	// The first page links to the next one
+	req, err := http.NewRequest("GET", "/api/stocks?limit=2", nil)
+	assert.NoError(t, err)
+	w := httptest.NewRecorder()
 	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
 
-	// Check the HTTP response status code for a bad request
-	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var page StockCursorPage
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	assert.Len(t, page.Data, 2)
+	assert.Equal(t, TempStockList[1].ID, page.Data[1].ID)
+	assert.NotNil(t, page.NextCursor)
+	assert.Equal(t, `</api/stocks?cursor=`+*page.NextCursor+`&limit=2>; rel="next"`, w.Header().Get("Link"))
+
+	// The last page has no next cursor
+	req, err = http.NewRequest("GET", "/api/stocks?limit=2&cursor="+*page.NextCursor, nil)
+	assert.NoError(t, err)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
 
-	// Perform assertions on the response body or error message
-	// Add more assertions as needed
+	page = StockCursorPage{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	assert.Len(t, page.Data, 1)
+	assert.Nil(t, page.NextCursor)
+	assert.Contains(t, w.Body.String(), `"nextCursor":null`)
+	assert.Empty(t, w.Header().Get("Link"))
+	mockDB.AssertExpectations(t)
 }
 
-func CreateStockWithMockDB(c *gin.Context) {
-	var stock repo.Stock
-	if err := c.ShouldBindJSON(&stock); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
+func TestGetStocksByCursorBadRequest(t *testing.T) {
+	h, mockDB := newMockHandler()
 
-	// Create a mock database
-	m := new(MockDB)
-	// Create a new stock in the mock database
-	if err := m.CreateStock(&stock); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create stock"})
-		return
+	r := newRouter()
+	r.GET("/api/stocks", h.GetStocks)
+
+	for _, query := range []string{"limit=0", "limit=101", "limit=ten", "cursor=garbage", "cursor=" + util.EncodeID(1), "limit=2&sort=name"} {
+		req, err := http.NewRequest("GET", "/api/stocks?"+query, nil)
+		assert.NoError(t, err)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
 	}
 
-	c.JSON(http.StatusCreated, stock)
+	// The repository is never reached
+	mockDB.AssertNotCalled(t, "FindStocks", mock.Anything, mock.Anything)
 }
 
+func TestSearchStocks(t *testing.T) {
+	// Create a mock repository returning a match
+	h, mockDB := newMockHandler()
+	mockDB.On("SearchStocks", mock.Anything, "micro", 5).Return([]repo.StockMatch{{Stock: TempStockList[2], Score: 0.8}}, nil)
 
+	r := newRouter()
+	RegisterRoutes(r.Group("", func(c *gin.Context) {
+		c.Set(middleware.ContextKeyIdentity, &middleware.Identity{Subject: "test", Roles: []string{middleware.RoleViewer}})
+	}), h)
 
+	// The search route doesn't collide with the stock IDs
+	req, err := http.NewRequest("GET", "/api/stocks/search?q=+micro+&limit=5", nil)
+	assert.NoError(t, err)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
 
+	var response []map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Len(t, response, 1)
+	assert.Equal(t, "Microsoft", response[0]["name"])
+	assert.Equal(t, TempStockList[2].ID.String(), response[0]["ID"])
+	assert.Equal(t, 0.8, response[0]["score"])
+
+	// Invalid searches never reach the repository
+	for _, query := range []string{"", "q=", "q=+++", "q=" + strings.Repeat("a", 101), "q=micro&limit=0"} {
+		req, err := http.NewRequest("GET", "/api/stocks/search?"+query, nil)
+		assert.NoError(t, err)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+	mockDB.AssertNumberOfCalls(t, "SearchStocks", 1)
+}
 
 func TestCreateStock(t *testing.T) {
+	// The repository assigns the ID, clients can't pick it
+	h, mockDB := newMockHandler()
+	mockDB.On("CreateStock", mock.Anything, mock.MatchedBy(func(stock *repo.Stock) bool { return stock.ID == 0 })).
+		Run(func(args mock.Arguments) { args.Get(1).(*repo.Stock).ID = 4 }).
+		Return(nil)
+
 	// Create a Gin router with the handler function
-	r := gin.Default()
-	r.POST("/api/stocks", CreateStockWithMockDB)
+	r := newRouter()
+	r.POST("/api/stocks", h.CreateStock)
 
 	// Create a sample stock to be sent in the request body
 	stock := repo.Stock{
 		ID:           4,
 		Name:         "Amazon",
-		CurrentPrice: 40.0,
+		Symbol:       "amzn ",
+		Exchange:     "Nasdaq",
+		CurrentPrice: util.MustParseDecimal("40.0"),
 		LastUpdate:   now,
 	}
 	stockJSON, _ := json.Marshal(stock)
@@ -228,37 +325,164 @@ func TestCreateStock(t *testing.T) {
 	// Perform assertions on the response data
 	assert.Equal(t, stock.ID, response.ID)
 	assert.Equal(t, stock.Name, response.Name)
+	assert.Equal(t, "AMZN", response.Symbol)
+	assert.Equal(t, "NASDAQ", response.Exchange)
 	assert.Equal(t, stock.CurrentPrice, response.CurrentPrice)
 	assert.Equal(t, stock.LastUpdate.UTC().Format(format), response.LastUpdate.UTC().Format(format))
+	mockDB.AssertExpectations(t)
 }
 
-func GetStockByIDWithMockDB(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, util.BadRequestResponse)
-		return
-	}
+func TestCreateStockConflict(t *testing.T) {
+	h, mockDB := newMockHandler()
+	mockDB.On("CreateStock", mock.Anything, mock.Anything).Return(repo.ErrConflict)
+
+	r := newRouter()
+	r.POST("/api/stocks", h.CreateStock)
+
+	// A symbol already listed on the exchange
+	req, err := http.NewRequest("POST", "/api/stocks", bytes.NewBufferString(`{"name":"Apple","symbol":"AAPL","exchange":"NASDAQ","currentPrice":10}`))
+	assert.NoError(t, err)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// A stock without symbol never reaches the repository
+	req, err = http.NewRequest("POST", "/api/stocks", bytes.NewBufferString(`{"name":"Apple","exchange":"NASDAQ","currentPrice":10}`))
+	assert.NoError(t, err)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	mockDB.AssertNumberOfCalls(t, "CreateStock", 1)
+}
 
-	// Create a mock database
-	m := new(MockDB)
-	// Retrieve a single stock from the mock database
-	stock, err := m.GetStockByID(uint(id))
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Stock not found"})
-		return
+func TestCreateStockValidation(t *testing.T) {
+	h, mockDB := newMockHandler()
+
+	r := newRouter()
+	r.POST("/api/stocks", h.CreateStock)
+
+	serve := func(body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("POST", "/api/stocks", bytes.NewBufferString(body))
+		assert.NoError(t, err)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	fields := func(w *httptest.ResponseRecorder) map[string]string {
+		var response util.Problem
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		fields := map[string]string{}
+		for _, field := range response.Fields {
+			fields[field.Field] = field.Message
+		}
+		return fields
 	}
 
-	c.JSON(http.StatusOK, *stock)
+	// Every invalid field is reported at once
+	w := serve(`{}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, map[string]string{
+		"name":         "is required",
+		"symbol":       "is required",
+		"exchange":     "is required",
+		"currentPrice": "must be greater than 0",
+	}, fields(w))
+
+	w = serve(`{"name":42,"symbol":"AMZN","exchange":"NASDAQ","currency":"dollars","currentPrice":"cheap","lastUpdate":"yesterday"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, map[string]string{
+		"name":         "must be a string",
+		"currency":     "must be an ISO 4217 code",
+		"currentPrice": "must be a decimal number",
+		"lastUpdate":   "must be an RFC 3339 date-time",
+	}, fields(w))
+
+	tomorrow := time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339)
+	w = serve(`{"name":"---","symbol":"AMZN","exchange":"NASDAQ","currentPrice":-1,"lastUpdate":"` + tomorrow + `"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, map[string]string{
+		"name":         "must contain a letter or a digit",
+		"currentPrice": "must be greater than 0",
+		"lastUpdate":   "must not be in the future",
+	}, fields(w))
+
+	// A body that isn't a stock object is a bad request, without echoing the parser error
+	w = serve(`{"name":`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, util.MIMEProblem, w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{
+		"type": "urn:stock-api:problem:json-unmarshal",
+		"title": "Malformed JSON body",
+		"status": 400,
+		"detail": "Invalid JSON body, expected a stock object",
+		"instance": "/api/stocks",
+		"code": "JSON_UNMARSHAL"
+	}`, w.Body.String())
+
+	mockDB.AssertNotCalled(t, "CreateStock", mock.Anything, mock.Anything)
+}
+
+func TestGetStockBySymbol(t *testing.T) {
+	h, mockDB := newMockHandler()
+	mockDB.On("GetStockBySymbol", mock.Anything, "NASDAQ", "AAPL").Return(&TempStockList[0], nil)
+	mockDB.On("GetStockBySymbol", mock.Anything, "NASDAQ", "TSLA").Return((*repo.Stock)(nil), repo.ErrStockNotFound)
+
+	r := newRouter()
+	RegisterRoutes(r.Group("", func(c *gin.Context) {
+		c.Set(middleware.ContextKeyIdentity, &middleware.Identity{Subject: "test", Roles: []string{middleware.RoleViewer}})
+	}), h)
+
+	// The lookup ignores case
+	req, err := http.NewRequest("GET", "/api/stocks/by-symbol/nasdaq/aapl", nil)
+	assert.NoError(t, err)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response repo.Stock
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, TempStockList[0].ID, response.ID)
+
+	req, err = http.NewRequest("GET", "/api/stocks/by-symbol/NASDAQ/TSLA", nil)
+	assert.NoError(t, err)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockDB.AssertExpectations(t)
+}
+
+func TestCreateStockFailure(t *testing.T) {
+	h, mockDB := newMockHandler()
+	mockDB.On("CreateStock", mock.Anything, mock.Anything).Return(errors.New("Error creating stock"))
+
+	// Create a Gin router with the handler function
+	r := newRouter()
+	r.POST("/api/stocks", h.CreateStock)
+
+	// Create a mock HTTP request with a stock the repository fails to create
+	req, err := http.NewRequest("POST", "/api/stocks", bytes.NewBufferString(`{"name":"Amazon","symbol":"AMZN","exchange":"NASDAQ","currentPrice":40}`))
+	assert.NoError(t, err)
+
+	// Create a mock HTTP response recorder
+	w := httptest.NewRecorder()
+
+	// Serve the request to the Gin router
+	r.ServeHTTP(w, req)
 
+	// Check the HTTP response status code
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
 }
 
 func TestGetStockByID(t *testing.T) {
+	h, mockDB := newMockHandler()
+	mockDB.On("GetStockByID", mock.Anything, uint(1)).Return(&TempStockList[0], nil)
+
 	// Create a Gin router with the handler function
-	r := gin.Default()
-	r.GET("/api/stocks/:id", GetStockByIDWithMockDB)
+	r := newRouter()
+	r.GET("/api/stocks/:id", h.GetStockByID)
 
 	// Create a mock HTTP request with a valid stock ID
-	id := "1"
+	id := util.EncodeID(1)
 	req, err := http.NewRequest("GET", "/api/stocks/"+id, nil)
 	assert.NoError(t, err)
 
@@ -277,53 +501,87 @@ func TestGetStockByID(t *testing.T) {
 	assert.NoError(t, err)
 
 	// Perform assertions on the response data
-	assert.Equal(t, uint(1), response.ID)
-	// Add more assertions as needed
+	assert.Equal(t, util.PublicID(1), response.ID)
+	assert.Equal(t, "Apple", response.Name)
+	mockDB.AssertExpectations(t)
 }
 
-func UpdateStockWithMockDB(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, util.BadRequestResponse)
-		return
-	}
+func TestGetStockByIDInvalidID(t *testing.T) {
+	h, _ := newMockHandler()
 
-	var updatedStock repo.Stock
-	if err := c.ShouldBindJSON(&updatedStock); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
+	// Create a Gin router with the handler function
+	r := newRouter()
+	r.GET("/api/stocks/:id", h.GetStockByID)
 
-	// Create a mock database
-	m := new(MockDB)
-	// Update the stock in the mock database
-	updatedStock.ID = uint(id)
-	if err := m.UpdateStock(&updatedStock); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update stock"})
-		return
-	}
+	// Create a mock HTTP request with a sequential instead of an opaque ID
+	req, err := http.NewRequest("GET", "/api/stocks/1", nil)
+	assert.NoError(t, err)
+
+	// Create a mock HTTP response recorder
+	w := httptest.NewRecorder()
 
-	c.JSON(http.StatusOK, updatedStock)
+	// Serve the request to the Gin router
+	r.ServeHTTP(w, req)
+
+	// Check the HTTP response status code for a bad request
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGetStockByIDRepoErrors(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{repo.ErrStockNotFound, http.StatusNotFound, "NOT_FOUND"},
+		{repo.ErrConflict, http.StatusConflict, "CONFLICT"},
+		// A database failure isn't reported as a missing stock
+		{errors.Join(repo.ErrUnavailable, errors.New("dial tcp: connection refused")), http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE"},
+		{errors.New("syntax error"), http.StatusInternalServerError, "INTERNAL"},
+	}
+	for _, test := range tests {
+		h, mockDB := newMockHandler()
+		mockDB.On("GetStockByID", mock.Anything, uint(1)).Return((*repo.Stock)(nil), test.err)
+
+		r := newRouter()
+		r.GET("/api/stocks/:id", h.GetStockByID)
+
+		req, err := http.NewRequest("GET", "/api/stocks/"+util.EncodeID(1), nil)
+		assert.NoError(t, err)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		var problem util.Problem
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+		assert.Equal(t, test.status, w.Code, test.err.Error())
+		assert.Equal(t, test.code, problem.Code, test.err.Error())
+		assert.NotContains(t, w.Body.String(), "connection refused")
+	}
 }
 
 func TestUpdateStock(t *testing.T) {
+	h, mockDB := newMockHandler()
+	mockDB.On("GetStockByID", mock.Anything, uint(1)).Return(&TempStockList[0], nil)
+	mockDB.On("UpdateStock", mock.Anything, mock.Anything).Return(nil)
+
 	// Create a Gin router with the handler function
-	r := gin.Default()
-	r.PATCH("/api/stocks/:id", UpdateStockWithMockDB)
+	r := newRouter()
+	r.PATCH("/api/stocks/:id", h.UpdateStock)
 
 	// Create a sample updated stock to be sent in the request body
 	updatedStock := repo.Stock{
 		ID:           1,
 		Name:         "Updated Apple",
-		CurrentPrice: 15.0,
+		CurrentPrice: util.MustParseDecimal("15.0"),
 		LastUpdate:   now,
 	}
 	updatedStockJSON, _ := json.Marshal(updatedStock)
 
 	// Create a mock HTTP request with the updated stock JSON in the request body
-	id := "1"
+	id := util.EncodeID(1)
 	req, err := http.NewRequest("PATCH", "/api/stocks/"+id, bytes.NewBuffer(updatedStockJSON))
 	assert.NoError(t, err)
+	req.Header.Set("If-Match", stockETag(&TempStockList[0]))
 
 	// Create a mock HTTP response recorder
 	w := httptest.NewRecorder()
@@ -344,33 +602,20 @@ func TestUpdateStock(t *testing.T) {
 	assert.Equal(t, updatedStock.Name, response.Name)
 	assert.Equal(t, updatedStock.CurrentPrice, response.CurrentPrice)
 	assert.Equal(t, updatedStock.LastUpdate.Format(format), response.LastUpdate.Format(format))
-}
-
-func DeleteStockWithMockDB(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, util.BadRequestResponse)
-		return
-	}
-
-	// Create a mock database
-	m := new(MockDB)
-	// Delete the stock from the mock database
-	if err := m.DeleteStock(uint(id)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete stock : " + err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "Stock deleted"})
+	mockDB.AssertExpectations(t)
 }
 
 func TestDeleteStock(t *testing.T) {
+	h, mockDB := newMockHandler()
+	mockDB.On("GetStockByID", mock.Anything, uint(1)).Return(&TempStockList[0], nil)
+	mockDB.On("DeleteStock", mock.Anything, uint(1)).Return(nil)
+
 	// Create a Gin router with the handler function
-	r := gin.Default()
-	r.DELETE("/api/stocks/:id", DeleteStockWithMockDB)
+	r := newRouter()
+	r.DELETE("/api/stocks/:id", h.DeleteStock)
 
 	// Create a mock HTTP request with a valid stock ID
-	id := "1"
+	id := util.EncodeID(1)
 	req, err := http.NewRequest("DELETE", "/api/stocks/"+id, nil)
 	assert.NoError(t, err)
 
@@ -380,20 +625,24 @@ func TestDeleteStock(t *testing.T) {
 	// Serve the request to the Gin router
 	r.ServeHTTP(w, req)
 
-	// Check the HTTP response status code
+	// Check the HTTP response status code and its documented body
 	assert.Equal(t, http.StatusOK, w.Code)
-
-	// Perform assertions on the response data or error message
-	// Add more assertions as needed
+	var response util.MessageResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "Stock deleted successfully", response.Message)
+	mockDB.AssertExpectations(t)
 }
 
 func TestDeleteStockNotFound(t *testing.T) {
+	h, mockDB := newMockHandler()
+	mockDB.On("GetStockByID", mock.Anything, uint(1000)).Return((*repo.Stock)(nil), repo.ErrStockNotFound)
+
 	// Create a Gin router with the handler function
-	r := gin.Default()
-	r.DELETE("/api/stocks/:id", DeleteStockWithMockDB)
+	r := newRouter()
+	r.DELETE("/api/stocks/:id", h.DeleteStock)
 
 	// Create a mock HTTP request with a stock ID that doesn't exist
-	id := "1000" // An ID that doesn't exist in TempStockList
+	id := util.EncodeID(1000) // An ID that doesn't exist in TempStockList
 	req, err := http.NewRequest("DELETE", "/api/stocks/"+id, nil)
 	assert.NoError(t, err)
 
@@ -403,7 +652,7 @@ func TestDeleteStockNotFound(t *testing.T) {
 	r.ServeHTTP(w, req)
 
 	// Check the HTTP response status code for a not found error
-	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, http.StatusNotFound, w.Code)
 
 	// Parse the JSON response body
 	var response map[string]interface{}
@@ -411,9 +660,445 @@ func TestDeleteStockNotFound(t *testing.T) {
 	assert.NoError(t, err)
 
 	// Perform assertions on the response data
-	expectedError := "Failed to delete stock : Stock not found"
-	actualError, ok := response["error"].(string)
+	expectedError := "Stock not found"
+	actualError, ok := response["detail"].(string)
 	assert.True(t, ok)
 	assert.Equal(t, expectedError, actualError)
-	// Add more assertions as needed
-}
\ No newline at end of file
+	assert.Equal(t, "NOT_FOUND", response["code"])
+
+	// The stock is never deleted
+	mockDB.AssertNotCalled(t, "DeleteStock", mock.Anything, mock.Anything)
+}
+
+func TestGetStockPricesBadRequest(t *testing.T) {
+	h, _ := newMockHandler()
+
+	// Create a Gin router with the handler function
+	r := newRouter()
+	r.GET("/api/stocks/:id/prices", h.GetStockPrices)
+
+	// Create a mock HTTP request with an invalid time range
+	req, err := http.NewRequest("GET", "/api/stocks/"+util.EncodeID(1)+"/prices?from=yesterday", nil)
+	assert.NoError(t, err)
+
+	// Create a mock HTTP response recorder
+	w := httptest.NewRecorder()
+
+	// Serve the request to the Gin router
+	r.ServeHTTP(w, req)
+
+	// Check the HTTP response status code for a bad request
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGetStockCandlesBadRequest(t *testing.T) {
+	h, _ := newMockHandler()
+
+	// Create a Gin router with the handler function
+	r := newRouter()
+	r.GET("/api/stocks/:id/candles", h.GetStockCandles)
+
+	// Create a mock HTTP request with an unsupported interval
+	req, err := http.NewRequest("GET", "/api/stocks/"+util.EncodeID(1)+"/candles?interval=2m", nil)
+	assert.NoError(t, err)
+
+	// Create a mock HTTP response recorder
+	w := httptest.NewRecorder()
+
+	// Serve the request to the Gin router
+	r.ServeHTTP(w, req)
+
+	// Check the HTTP response status code for a bad request
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestStockLifecycleWithMemoryRepo(t *testing.T) {
+	// Create a handler backed by a real in-memory store
+	h := NewStockHandler(repo.NewMemoryStockRepo(), repo.NewMemoryFxRateRepo())
+
+	r := newRouter()
+	RegisterRoutes(r.Group("", func(c *gin.Context) {
+		c.Set(middleware.ContextKeyIdentity, &middleware.Identity{Subject: "test", Roles: []string{middleware.RoleEditor}})
+	}), h)
+
+	serve := func(method, path, body string, headers ...string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, path, bytes.NewBufferString(body))
+		assert.NoError(t, err)
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// Create a stock and read it back through its opaque ID
+	w := serve("POST", "/api/stocks", `{"name":"Amazon","symbol":"AMZN","exchange":"NASDAQ","currentPrice":40}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var created repo.Stock
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+
+	w = serve("GET", "/api/stocks/"+created.ID.String(), "")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Update its price, which is recorded in the price history
+	w = serve("PATCH", "/api/stocks/"+created.ID.String(), `{"name":"Amazon","currentPrice":42}`, "If-Match", w.Header().Get("ETag"))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = serve("GET", "/api/stocks/"+created.ID.String()+"/prices", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var prices []repo.StockPrice
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &prices))
+	assert.Len(t, prices, 2)
+
+	// Walk every stock by cursor
+	serve("POST", "/api/stocks", `{"name":"Tesla","symbol":"TSLA","exchange":"NASDAQ","currentPrice":50}`)
+	serve("POST", "/api/stocks", `{"name":"Netflix","symbol":"NFLX","exchange":"NASDAQ","currentPrice":60}`)
+
+	// The symbols are unique on an exchange, and the update keeps them
+	w = serve("POST", "/api/stocks", `{"name":"Tesla again","symbol":"tsla","exchange":"nasdaq","currentPrice":50}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = serve("GET", "/api/stocks/by-symbol/NASDAQ/AMZN", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"currentPrice":42`)
+
+	names := []string{}
+	for path := "/api/stocks?limit=2"; ; {
+		w = serve("GET", path, "")
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var page StockCursorPage
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		for _, stock := range page.Data {
+			names = append(names, stock.Name)
+		}
+		if page.NextCursor == nil {
+			break
+		}
+		path = "/api/stocks?limit=2&cursor=" + *page.NextCursor
+	}
+	assert.Equal(t, []string{"Amazon", "Tesla", "Netflix"}, names)
+
+	// Delete it
+	w = serve("DELETE", "/api/stocks/"+created.ID.String(), "")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = serve("GET", "/api/stocks/"+created.ID.String(), "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestGetStocksInCurrency(t *testing.T) {
+	// Create a handler backed by real in-memory stores holding a KRW to USD rate
+	ctx := context.Background()
+	stocks, rates := repo.NewMemoryStockRepo(), repo.NewMemoryFxRateRepo()
+	asOf := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	assert.NoError(t, rates.SaveFxRates(ctx, []repo.FxRate{{Base: "KRW", Quote: "USD", Rate: util.MustParseRate("0.00072"), AsOf: asOf}}))
+
+	samsung := repo.Stock{Name: "Samsung", Symbol: "005930", Exchange: "KRX", Currency: "KRW", CurrentPrice: util.MustParseDecimal("71000")}
+	assert.NoError(t, stocks.CreateStock(ctx, &samsung))
+	apple := repo.Stock{Name: "Apple", Symbol: "AAPL", Exchange: "NASDAQ", Currency: "USD", CurrentPrice: util.MustParseDecimal("100.5")}
+	assert.NoError(t, stocks.CreateStock(ctx, &apple))
+
+	r := newRouter()
+	RegisterRoutes(r.Group("", func(c *gin.Context) {
+		c.Set(middleware.ContextKeyIdentity, &middleware.Identity{Subject: "test", Roles: []string{middleware.RoleViewer}})
+	}), NewStockHandler(stocks, rates))
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("GET", path, nil)
+		assert.NoError(t, err)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// A single stock is converted along with the rate used
+	w := serve("/api/stocks/" + samsung.ID.String() + "?currency=usd")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var stock repo.Stock
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &stock))
+	assert.Equal(t, "51.12", stock.CurrentPrice.String())
+	assert.Equal(t, "USD", stock.Currency)
+	assert.Equal(t, "KRW", stock.Fx.From)
+	assert.True(t, asOf.Equal(stock.Fx.AsOf))
+
+	// Its ETag is weak as the converted body differs from the stock, unless nothing was converted
+	assert.Equal(t, `W/"1"`, w.Header().Get("ETag"))
+	assert.Equal(t, `"1"`, serve("/api/stocks/"+samsung.ID.String()).Header().Get("ETag"))
+	assert.Equal(t, `"1"`, serve("/api/stocks/"+apple.ID.String()+"?currency=USD").Header().Get("ETag"))
+
+	// Lists are converted in both pagination modes, stocks priced in USD are left as is
+	for _, path := range []string{"/api/stocks?currency=USD", "/api/stocks?limit=10&currency=USD"} {
+		w = serve(path)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var page struct {
+			Data []repo.Stock `json:"data"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		assert.Len(t, page.Data, 2)
+		assert.Equal(t, "51.12", page.Data[0].CurrentPrice.String())
+		assert.Equal(t, "100.5", page.Data[1].CurrentPrice.String())
+		assert.Nil(t, page.Data[1].Fx)
+	}
+
+	// Without currency the prices stay in the currency of each stock
+	w = serve("/api/stocks/by-symbol/KRX/005930")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"currentPrice":71000`)
+	assert.NotContains(t, w.Body.String(), `"fx"`)
+
+	// Unknown rates and invalid currencies are rejected
+	assert.Equal(t, http.StatusBadRequest, serve("/api/stocks/"+apple.ID.String()+"?currency=KRW").Code)
+	assert.Equal(t, http.StatusBadRequest, serve("/api/stocks?currency=dollars").Code)
+}
+
+func TestDeleteAndRestoreStock(t *testing.T) {
+	// Create a handler backed by a real in-memory store, callers pick their role
+	h := NewStockHandler(repo.NewMemoryStockRepo(), repo.NewMemoryFxRateRepo())
+
+	r := newRouter()
+	RegisterRoutes(r.Group("", func(c *gin.Context) {
+		c.Set(middleware.ContextKeyIdentity, &middleware.Identity{Subject: "test", Roles: []string{c.GetHeader("X-Role")}})
+	}), h)
+
+	serve := func(role, method, path, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, path, bytes.NewBufferString(body))
+		assert.NoError(t, err)
+		req.Header.Set("X-Role", role)
+		// no concurrent editors here
+		req.Header.Set("If-Match", "*")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve(middleware.RoleEditor, "POST", "/api/stocks", `{"name":"Amazon","symbol":"AMZN","exchange":"NASDAQ","currentPrice":40}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var created repo.Stock
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	path := "/api/stocks/" + created.ID.String()
+
+	// Delete it, it is then hidden
+	assert.Equal(t, http.StatusOK, serve(middleware.RoleEditor, "DELETE", path, "").Code)
+	assert.Equal(t, http.StatusNotFound, serve(middleware.RoleViewer, "GET", path, "").Code)
+
+	// Only admins can list the deleted stocks
+	assert.Equal(t, http.StatusForbidden, serve(middleware.RoleEditor, "GET", "/api/stocks?includeDeleted=true", "").Code)
+
+	w = serve(middleware.RoleAdmin, "GET", "/api/stocks?includeDeleted=true", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"deletedAt":"`)
+
+	// Restore it, once
+	assert.Equal(t, http.StatusForbidden, serve(middleware.RoleViewer, "POST", path+"/restore", "").Code)
+	w = serve(middleware.RoleEditor, "POST", path+"/restore", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"deletedAt":null`)
+
+	assert.Equal(t, http.StatusOK, serve(middleware.RoleViewer, "GET", path, "").Code)
+	assert.Equal(t, http.StatusNotFound, serve(middleware.RoleEditor, "POST", path+"/restore", "").Code)
+
+	// Clients can't delete a stock through an update
+	w = serve(middleware.RoleEditor, "PATCH", path, `{"name":"Amazon","currentPrice":41,"deletedAt":"2024-01-02T10:00:00Z"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, http.StatusOK, serve(middleware.RoleViewer, "GET", path, "").Code)
+}
+
+func TestUpdateStockPreconditions(t *testing.T) {
+	h := NewStockHandler(repo.NewMemoryStockRepo(), repo.NewMemoryFxRateRepo())
+
+	r := newRouter()
+	RegisterRoutes(r.Group("", func(c *gin.Context) {
+		c.Set(middleware.ContextKeyIdentity, &middleware.Identity{Subject: "test", Roles: []string{middleware.RoleEditor}})
+	}), h)
+
+	serve := func(method, path, body, ifMatch string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, path, bytes.NewBufferString(body))
+		assert.NoError(t, err)
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve("POST", "/api/stocks", `{"name":"Amazon","symbol":"AMZN","exchange":"NASDAQ","currentPrice":40}`, "")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, `"1"`, w.Header().Get("ETag"))
+
+	var created repo.Stock
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	path := "/api/stocks/" + created.ID.String()
+
+	w = serve("GET", path, "", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	assert.Equal(t, `"1"`, etag)
+
+	// Updates must tell which version they change, with a strong ETag
+	assert.Equal(t, http.StatusPreconditionRequired, serve("PATCH", path, `{"name":"Amazon","currentPrice":41}`, "").Code)
+	assert.Equal(t, http.StatusPreconditionFailed, serve("PATCH", path, `{"name":"Amazon","currentPrice":41}`, "W/"+etag).Code)
+
+	// The first editor wins, the second one edited a stale version
+	w = serve("PATCH", path, `{"name":"Amazon","currentPrice":41}`, etag)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+	assert.Contains(t, w.Body.String(), `"version":2`)
+
+	w = serve("PATCH", path, `{"name":"Amazon","currentPrice":39}`, etag)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+
+	w = serve("GET", path, "", "")
+	assert.Contains(t, w.Body.String(), `"currentPrice":41`)
+
+	// Any of a list of ETags can match
+	w = serve("PATCH", path, `{"name":"Amazon","currentPrice":42}`, `"1", "2"`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+}
+
+func TestPatchAndReplaceStock(t *testing.T) {
+	memory := repo.NewMemoryStockRepo()
+	h := NewStockHandler(memory, repo.NewMemoryFxRateRepo())
+
+	r := newRouter()
+	RegisterRoutes(r.Group("", func(c *gin.Context) {
+		c.Set(middleware.ContextKeyIdentity, &middleware.Identity{Subject: "test", Roles: []string{middleware.RoleEditor}})
+	}), h)
+
+	serve := func(method, path, contentType, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, path, bytes.NewBufferString(body))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("If-Match", "*")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	stock := func(w *httptest.ResponseRecorder) repo.Stock {
+		var stock repo.Stock
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &stock))
+		return stock
+	}
+
+	w := serve("POST", "/api/stocks", "application/json",
+		`{"name":"Amazon","symbol":"AMZN","exchange":"NASDAQ","currency":"USD","isin":"US0231351067","currentPrice":40,"lastUpdate":"2024-01-02T10:00:00Z"}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	path := "/api/stocks/" + stock(w).ID.String()
+
+	// A merge patch only changes the given fields
+	w = serve("PATCH", path, "application/merge-patch+json", `{"currentPrice":41,"lastUpdate":"2024-01-02T11:00:00Z"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	patched := stock(w)
+	assert.Equal(t, "Amazon", patched.Name)
+	assert.Equal(t, "41", patched.CurrentPrice.String())
+	assert.Equal(t, "USD", patched.Currency)
+	assert.Equal(t, "US0231351067", patched.ISIN)
+	assert.Equal(t, "2024-01-02T11:00:00Z", patched.LastUpdate.Format(time.RFC3339))
+
+	w = serve("PATCH", path, "application/merge-patch+json", `{"name":"Amazon"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2024-01-02T11:00:00Z", stock(w).LastUpdate.Format(time.RFC3339))
+
+	// Patching the price alone updates it now, so its tick isn't back-dated
+	w = serve("PATCH", path, "application/merge-patch+json", `{"currentPrice":42}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.WithinDuration(t, time.Now(), stock(w).LastUpdate, time.Minute)
+
+	prices, err := memory.GetStockPrices(context.Background(), uint(patched.ID), time.Time{}, time.Time{})
+	assert.NoError(t, err)
+	assert.Len(t, prices, 3)
+	assert.Equal(t, "42", prices[2].Price.String())
+	assert.True(t, stock(w).LastUpdate.Equal(prices[2].RecordedAt))
+	assert.True(t, prices[2].RecordedAt.After(prices[1].RecordedAt))
+
+	// null resets a field, except the required ones
+	w = serve("PATCH", path, "application/merge-patch+json", `{"isin":null}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "", stock(w).ISIN)
+	assert.Equal(t, "Amazon", stock(w).Name)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, serve("PATCH", path, "application/merge-patch+json", `{"symbol":null}`).Code)
+	assert.Equal(t, http.StatusBadRequest, serve("PATCH", path, "application/merge-patch+json", `[{"op":"remove","path":"/name"}]`).Code)
+	assert.Equal(t, http.StatusUnsupportedMediaType, serve("PATCH", path, "application/json-patch+json", `[{"op":"remove","path":"/name"}]`).Code)
+
+	// A replacement resets the omitted fields, lastUpdate to now
+	assert.Equal(t, http.StatusUnprocessableEntity, serve("PUT", path, "application/json", `{"name":"Amazon.com","currentPrice":43}`).Code)
+
+	w = serve("PUT", path, "application/json", `{"name":"Amazon.com","symbol":"AMZN","exchange":"NASDAQ","currentPrice":43}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	replaced := stock(w)
+	assert.Equal(t, "Amazon.com", replaced.Name)
+	assert.Equal(t, "", replaced.Currency)
+	assert.WithinDuration(t, time.Now(), replaced.LastUpdate, time.Minute)
+
+	w = serve("GET", path, "", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, replaced.Name, stock(w).Name)
+	assert.Equal(t, `"6"`, w.Header().Get("ETag"))
+}
+
+func TestTimesInOtherZonesSQLite(t *testing.T) {
+	db := &repo.Database{
+		Driver:   repo.DriverSQLite,
+		DbPath:   filepath.Join(t.TempDir(), "stock.db"),
+		LogLevel: logger.Silent,
+	}
+	db.Connect()
+	t.Cleanup(db.Close)
+	migrator, err := repo.NewMigrator(db.DB())
+	assert.NoError(t, err)
+	_, err = migrator.Up(context.Background())
+	assert.NoError(t, err)
+
+	h := NewStockHandler(repo.NewStockRepo(db), repo.NewFxRateRepo(db))
+	r := newRouter()
+	RegisterRoutes(r.Group("", func(c *gin.Context) {
+		c.Set(middleware.ContextKeyIdentity, &middleware.Identity{Subject: "test", Roles: []string{middleware.RoleEditor}})
+	}), h)
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, path, bytes.NewBufferString(body))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", "*")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	count := func(updatedSince string) int64 {
+		w := serve("GET", "/api/stocks?name=Sony&updatedSince="+url.QueryEscape(updatedSince), "")
+		assert.Equal(t, http.StatusOK, w.Code)
+		var page util.PageResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		return page.Total
+	}
+
+	// Created at 18:00+09:00, that is 09:00Z
+	w := serve("POST", "/api/stocks", `{"name":"Sony","symbol":"SONY","exchange":"TSE","currentPrice":100,"lastUpdate":"2024-01-02T18:00:00+09:00"}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var stock repo.Stock
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &stock))
+	path := "/api/stocks/" + stock.ID.String()
+
+	assert.Equal(t, int64(1), count("2024-01-02T08:30:00Z"))
+	assert.Equal(t, int64(0), count("2024-01-02T09:30:00Z"))
+	assert.Equal(t, int64(1), count("2024-01-02T17:30:00+09:00"))
+
+	// Priced again at 10:00Z, both ticks are at or after 18:00+09:00
+	assert.Equal(t, http.StatusOK, serve("PATCH", path, `{"currentPrice":101,"lastUpdate":"2024-01-02T10:00:00Z"}`).Code)
+
+	w = serve("GET", path+"/prices?from="+url.QueryEscape("2024-01-02T18:00:00+09:00"), "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var prices []repo.StockPrice
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &prices))
+	assert.Len(t, prices, 2)
+}
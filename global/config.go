@@ -1,8 +1,13 @@
 package global
 
 import (
+	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
 func getEnv(key, fallback string) string {
@@ -34,6 +39,44 @@ func getEnvInt(key string, fallback int) int {
 	return fallback
 }
 
+// getEnvDuration parses a duration such as "500ms" or "5s".
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	if val, ok := os.LookupEnv(key); ok {
+		valDuration, err := time.ParseDuration(val)
+		if err != nil {
+			return fallback
+		}
+		return valDuration
+	}
+	return fallback
+}
+
+// parseAuthUsers parses comma separated "username:hash:role" entries, hash being the
+// bcrypt hash of the password and role defaulting to viewer. A bcrypt hash holds no
+// separator, so each entry is only cut on the first separator after each field.
+// Entries without a username or a valid hash are ignored.
+func parseAuthUsers(value string) map[string]AuthUser {
+	users := map[string]AuthUser{}
+	for _, item := range strings.Split(value, ",") {
+		username, credentials, ok := strings.Cut(strings.TrimSpace(item), ":")
+		username = strings.TrimSpace(username)
+		if !ok || username == "" {
+			continue
+		}
+
+		hash, role, _ := strings.Cut(credentials, ":")
+		if _, err := bcrypt.Cost([]byte(hash)); err != nil {
+			log.Printf("AUTH_USERS: ignoring user %q, its password must be a bcrypt hash", username)
+			continue
+		}
+		if role == "" {
+			role = "viewer"
+		}
+		users[username] = AuthUser{PasswordHash: hash, Role: role}
+	}
+	return users
+}
+
 func GetEnvConfig() *VecConfig {
 	if Config == nil {
 		FetchEnvs()
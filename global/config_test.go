@@ -0,0 +1,24 @@
+package global
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestParseAuthUsers(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("pa:ss,word"), bcrypt.MinCost)
+	assert.NoError(t, err)
+
+	users := parseAuthUsers("admin:" + string(hash) + ":admin, bob:" + string(hash) + ",plain:change-me:admin,:" + string(hash) + ",nobody")
+	assert.Equal(t, map[string]AuthUser{
+		"admin": {PasswordHash: string(hash), Role: "admin"},
+		"bob":   {PasswordHash: string(hash), Role: "viewer"},
+	}, users)
+
+	// Passwords holding separators are kept whole, only their hash is configured
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(users["admin"].PasswordHash), []byte("pa:ss,word")))
+
+	assert.Empty(t, parseAuthUsers(""))
+}
@@ -19,7 +19,14 @@ type VecConfig struct {
 	ServerPort string
 	Prefix     string
 
+	// Storage
+	// StorageDriver selects where data is kept: "database" or "memory"
+	StorageDriver string
+
 	// DB
+	// DbDriver selects the database: "postgres" or "sqlite"
+	DbDriver   string
+	DbPath     string
 	DbHost     string
 	DbPort     string
 	DbUsername string
@@ -27,6 +34,13 @@ type VecConfig struct {
 	DbName     string
 	DbSSLMode  string
 	DbTimeZone string
+	// DbQueryTimeout bounds every query, zero disables the timeout
+	DbQueryTimeout time.Duration
+	// StockRetention is how long deleted stocks can be restored before they are purged,
+	// zero disables the purge
+	StockRetention time.Duration
+	// StockPurgeInterval is how often deleted stocks past their retention are purged
+	StockPurgeInterval time.Duration
 
 	// Keys
 	EncodeIdKey string
@@ -34,9 +48,25 @@ type VecConfig struct {
 	// JWTAuthorize
 	SecretKey          string
 	DefaultJWTDuration time.Duration
+	// AuthUsers maps the usernames allowed to request a token to their credentials
+	AuthUsers map[string]AuthUser
+	// AuthMode selects how callers are identified: "jwt" or "header" (dev only)
+	AuthMode string
+}
+
+// AuthUser represents the credentials and role of a user allowed to request a token.
+type AuthUser struct {
+	// PasswordHash is the bcrypt hash of the password
+	PasswordHash string
+	Role         string
 }
 
 func (cf *VecConfig) initDB() {
+	cf.StorageDriver = getEnv("STORAGE_DRIVER", "database")
+
+	cf.DbDriver = getEnv("DB_DRIVER", "postgres")
+	/* SQLite DB file, only used by the sqlite driver. */
+	cf.DbPath = getEnv("DB_PATH", "stock.db")
 
 	/* Postgres DB. Change all DB identifiers below. */
 	cf.DbHost = getEnv("DB_HOST", "127.0.0.1")
@@ -46,6 +76,11 @@ func (cf *VecConfig) initDB() {
 	cf.DbName = getEnv("DB_NAME", "")
 	cf.DbSSLMode = getEnv("DB_SSL_MODE", "disable")
 	cf.DbTimeZone = getEnv("DB_TIME_ZONE", "GMT")
+	cf.DbQueryTimeout = getEnvDuration("DB_QUERY_TIMEOUT", 5*time.Second)
+
+	/* Deleted stocks are kept 30 days by default */
+	cf.StockRetention = getEnvDuration("STOCK_RETENTION", 30*24*time.Hour)
+	cf.StockPurgeInterval = getEnvDuration("STOCK_PURGE_INTERVAL", time.Hour)
 
 }
 
@@ -58,11 +93,16 @@ func (cf *VecConfig) initWeb() {
 func (cf *VecConfig) initAuth() {
 	cf.SecretKey = getEnv("JWT_KEY", "")
 
-	/* This key was used to generate the session token information. */
-	cf.EncodeIdKey = "encode-id-key"
+	/* This key was used to generate the session token information and the public ids. */
+	cf.EncodeIdKey = getEnv("ENCODE_ID_KEY", "encode-id-key")
 
 	/* Time duration variables */
 	cf.DefaultJWTDuration = time.Hour * 1
+
+	/* Comma separated "username:bcrypt-hash:role" entries, role defaults to viewer */
+	cf.AuthUsers = parseAuthUsers(getEnv("AUTH_USERS", ""))
+
+	cf.AuthMode = getEnv("AUTH_MODE", "jwt")
 }
 
 func newVecConfig() *VecConfig {
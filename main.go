@@ -1,20 +1,104 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
 	"stock-api/api-portal/routes"
 	"stock-api/global"
 	"stock-api/repo"
+	"stock-api/util"
 )
 
+const usage = `usage:
+  stock-api                  run the api
+  stock-api migrate up       apply every pending migration
+  stock-api migrate down     revert the latest applied migration
+  stock-api migrate status   list the migrations and when they were applied`
+
 func main() {
 	// fetch env
 	global.FetchEnvs()
+	// key public ids
+	util.SetIDKey(global.Config.EncodeIdKey)
 	// init db
 	repo.Init()
 
-	// migrate db
-	repo.DoMigration()
+	if len(os.Args) > 1 {
+		if os.Args[1] != "migrate" || len(os.Args) != 3 {
+			log.Fatal(usage)
+		}
+		if err := migrate(os.Args[2]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// refuse to serve an outdated schema
+	repo.CheckMigrations()
+
+	// purge the deleted stocks past their retention
+	if global.Config.StockRetention > 0 && global.Config.StockPurgeInterval > 0 {
+		go repo.PurgeDeletedStocks(context.Background(), repo.Server.StockRepo, global.Config.StockRetention, global.Config.StockPurgeInterval)
+	}
 
 	// init routes
 	routes.Init()
 }
+
+// migrate runs a migrate subcommand against the configured database.
+func migrate(command string) error {
+	if global.Config.StorageDriver == repo.StorageDriverMemory {
+		return fmt.Errorf("the %s storage driver has no schema to migrate", repo.StorageDriverMemory)
+	}
+
+	migrator, err := repo.NewMigrator(repo.DB.DB())
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	switch command {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, migration := range applied {
+			fmt.Printf("applied %d_%s\n", migration.Version, migration.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("no pending migration")
+		}
+		return err
+	case "down":
+		reverted, err := migrator.Down(ctx)
+		if err != nil {
+			return err
+		}
+		if reverted == nil {
+			fmt.Println("no applied migration")
+		} else {
+			fmt.Printf("reverted %d_%s\n", reverted.Version, reverted.Name)
+		}
+		return nil
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown migrate command %q\n%s", command, usage)
+	}
+}
@@ -0,0 +1,152 @@
+package repo
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ApiKeyScopes holds the roles granted to an API key, stored comma separated.
+type ApiKeyScopes []string
+
+// Value implements driver.Valuer.
+func (s ApiKeyScopes) Value() (driver.Value, error) {
+	return strings.Join(s, ","), nil
+}
+
+// Scan implements sql.Scanner.
+func (s *ApiKeyScopes) Scan(value interface{}) error {
+	var str string
+	switch v := value.(type) {
+	case string:
+		str = v
+	case []byte:
+		str = string(v)
+	case nil:
+		str = ""
+	default:
+		return fmt.Errorf("can't scan %T into ApiKeyScopes", value)
+	}
+
+	*s = ApiKeyScopes{}
+	if str != "" {
+		*s = strings.Split(str, ",")
+	}
+	return nil
+}
+
+// GormDataType stores the scopes as a string column.
+func (ApiKeyScopes) GormDataType() string {
+	return "string"
+}
+
+// ApiKey represents a credential used by machine clients.
+// Only the hash of the key secret is stored.
+type ApiKey struct {
+	ID         uint         `gorm:"primarykey" json:"id"`
+	Prefix     string       `gorm:"uniqueIndex;not null" json:"prefix"`
+	SecretHash string       `gorm:"not null" json:"-"`
+	Owner      string       `gorm:"not null" json:"owner"`
+	Scopes     ApiKeyScopes `json:"scopes"`
+	ExpiresAt  *time.Time   `json:"expiresAt"`
+	LastUsedAt *time.Time   `json:"lastUsedAt"`
+	RevokedAt  *time.Time   `json:"revokedAt"`
+	CreatedAt  time.Time    `json:"createdAt"`
+}
+
+// Active reports whether the key is neither revoked nor expired at the given time.
+func (k *ApiKey) Active(at time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || at.Before(*k.ExpiresAt)
+}
+
+// ApiKeyRepo represents the repository of API keys.
+type ApiKeyRepo struct {
+	Db *Database
+}
+
+type ApiKeyRepository interface {
+	CreateApiKey(ctx context.Context, key *ApiKey) error
+	GetApiKeys(ctx context.Context) ([]ApiKey, error)
+	GetApiKeyByPrefix(ctx context.Context, prefix string) (*ApiKey, error)
+	RevokeApiKey(ctx context.Context, id uint) error
+	TouchApiKey(ctx context.Context, id uint, usedAt time.Time) error
+}
+
+// NewApiKeyRepo initializes a new ApiKeyRepo with a GORM instance.
+func NewApiKeyRepo(db *Database) *ApiKeyRepo {
+	return &ApiKeyRepo{db}
+}
+
+// CreateApiKey inserts a new API key into the database.
+func (repo *ApiKeyRepo) CreateApiKey(ctx context.Context, key *ApiKey) error {
+	db, cancel := repo.Db.WithContext(ctx)
+	defer cancel()
+
+	result := db.Create(key)
+	if result.Error != nil {
+		return translateApiKeyError(result.Error)
+	}
+	return nil
+}
+
+// GetApiKeys retrieves every API key from the database.
+func (repo *ApiKeyRepo) GetApiKeys(ctx context.Context) ([]ApiKey, error) {
+	db, cancel := repo.Db.WithContext(ctx)
+	defer cancel()
+
+	var keys []ApiKey
+	result := db.Order("id ASC").Find(&keys)
+	if result.Error != nil {
+		return nil, translateApiKeyError(result.Error)
+	}
+	return keys, nil
+}
+
+// GetApiKeyByPrefix retrieves a single API key by its public prefix.
+// It fails with ErrApiKeyNotFound when no key has the prefix, and with
+// ErrUnavailable when the database can't be reached.
+func (repo *ApiKeyRepo) GetApiKeyByPrefix(ctx context.Context, prefix string) (*ApiKey, error) {
+	db, cancel := repo.Db.WithContext(ctx)
+	defer cancel()
+
+	var key ApiKey
+	result := db.Where("prefix = ?", prefix).First(&key)
+	if result.Error != nil {
+		return nil, translateApiKeyError(result.Error)
+	}
+	return &key, nil
+}
+
+// RevokeApiKey marks an API key as revoked.
+func (repo *ApiKeyRepo) RevokeApiKey(ctx context.Context, id uint) error {
+	db, cancel := repo.Db.WithContext(ctx)
+	defer cancel()
+
+	result := db.Model(&ApiKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return translateApiKeyError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrApiKeyNotFound
+	}
+	return nil
+}
+
+// TouchApiKey records the last time an API key was used.
+func (repo *ApiKeyRepo) TouchApiKey(ctx context.Context, id uint, usedAt time.Time) error {
+	db, cancel := repo.Db.WithContext(ctx)
+	defer cancel()
+
+	result := db.Model(&ApiKey{}).Where("id = ?", id).Update("last_used_at", usedAt)
+	if result.Error != nil {
+		return translateApiKeyError(result.Error)
+	}
+	return nil
+}
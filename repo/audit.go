@@ -0,0 +1,208 @@
+package repo
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"stock-api/util"
+)
+
+// Audited entities.
+const AuditEntityStock = "stock"
+
+// Audited actions.
+const (
+	AuditActionCreate  = "create"
+	AuditActionUpdate  = "update"
+	AuditActionDelete  = "delete"
+	AuditActionRestore = "restore"
+	AuditActionPurge   = "purge"
+)
+
+// AuditActorSystem is the actor of mutations made outside of a request.
+const AuditActorSystem = "system"
+
+// AuditInfo tells who made a mutation, and in which request.
+type AuditInfo struct {
+	Actor     string
+	RequestID string
+}
+
+type auditInfoKey struct{}
+
+// WithAuditInfo returns a copy of ctx recording info in the audit events of the mutations made with it.
+func WithAuditInfo(ctx context.Context, info AuditInfo) context.Context {
+	return context.WithValue(ctx, auditInfoKey{}, info)
+}
+
+// AuditInfoFromContext returns the audit info of ctx, the system actor when there is none.
+func AuditInfoFromContext(ctx context.Context) AuditInfo {
+	info, _ := ctx.Value(auditInfoKey{}).(AuditInfo)
+	if info.Actor == "" {
+		info.Actor = AuditActorSystem
+	}
+	return info
+}
+
+// AuditChange holds the JSON value of a field before and after a mutation, null when absent.
+type AuditChange struct {
+	Before json.RawMessage `json:"before" swaggertype:"object"`
+	After  json.RawMessage `json:"after" swaggertype:"object"`
+}
+
+// AuditChanges maps the JSON names of the changed fields to their change, stored as JSON.
+type AuditChanges map[string]AuditChange
+
+// Value implements driver.Valuer.
+func (c AuditChanges) Value() (driver.Value, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner.
+func (c *AuditChanges) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	case nil:
+		*c = AuditChanges{}
+		return nil
+	default:
+		return fmt.Errorf("can't scan %T into AuditChanges", value)
+	}
+
+	changes := AuditChanges{}
+	if err := json.Unmarshal(data, &changes); err != nil {
+		return err
+	}
+	*c = changes
+	return nil
+}
+
+// GormDataType stores the changes as a string column.
+func (AuditChanges) GormDataType() string {
+	return "string"
+}
+
+// AuditEvent records who changed an entity, when, and how.
+type AuditEvent struct {
+	ID        uint          `gorm:"primarykey" json:"id"`
+	Entity    string        `gorm:"index:idx_audit_events_entity,priority:1;not null" json:"entity" example:"stock"`
+	EntityID  util.PublicID `gorm:"index:idx_audit_events_entity,priority:2;not null" json:"entityId" swaggertype:"string"`
+	Action    string        `gorm:"not null" json:"action" example:"update"`
+	Actor     string        `gorm:"not null" json:"actor"`
+	RequestID string        `gorm:"not null" json:"requestId"`
+	Changes   AuditChanges  `json:"changes"`
+	CreatedAt time.Time     `json:"createdAt"`
+}
+
+// AuditQuery filters and paginates the audit log, newest events first.
+// Zero fields are ignored.
+type AuditQuery struct {
+	Entity   string
+	EntityID uint
+	Offset   int
+	Limit    int
+}
+
+// Matches reports whether an event passes the filters of the query.
+func (q AuditQuery) Matches(event AuditEvent) bool {
+	if q.Entity != "" && event.Entity != q.Entity {
+		return false
+	}
+	return q.EntityID == 0 || uint(event.EntityID) == q.EntityID
+}
+
+// AuditRepository reads the audit log. Events are written by the repositories
+// of the audited entities, along with the mutation they record.
+type AuditRepository interface {
+	FindAuditEvents(ctx context.Context, query AuditQuery) ([]AuditEvent, error)
+	CountAuditEvents(ctx context.Context, query AuditQuery) (int64, error)
+}
+
+// newStockAuditEvent records a mutation of a stock from its version before
+// to its version after, either being nil when the stock didn't exist.
+func newStockAuditEvent(ctx context.Context, action string, before, after *Stock) (*AuditEvent, error) {
+	changes, err := diffJSON(before, after)
+	if err != nil {
+		return nil, err
+	}
+
+	id := util.PublicID(0)
+	if after != nil {
+		id = after.ID
+	} else if before != nil {
+		id = before.ID
+	}
+
+	info := AuditInfoFromContext(ctx)
+	return &AuditEvent{
+		Entity:    AuditEntityStock,
+		EntityID:  id,
+		Action:    action,
+		Actor:     info.Actor,
+		RequestID: info.RequestID,
+		Changes:   changes,
+		CreatedAt: time.Now().UTC(),
+	}, nil
+}
+
+// diffJSON returns the fields whose JSON value differs between before and after.
+// A nil value has no fields.
+func diffJSON(before, after interface{}) (AuditChanges, error) {
+	beforeFields, err := jsonFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := jsonFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := AuditChanges{}
+	for name, value := range beforeFields {
+		if !bytes.Equal(value, afterFields[name]) {
+			changes[name] = AuditChange{Before: value, After: jsonOrNull(afterFields[name])}
+		}
+	}
+	for name, value := range afterFields {
+		if _, ok := beforeFields[name]; !ok {
+			changes[name] = AuditChange{Before: jsonOrNull(nil), After: value}
+		}
+	}
+	return changes, nil
+}
+
+// jsonFields returns the JSON encoded fields of an object, none for a nil pointer.
+func jsonFields(value interface{}) (map[string]json.RawMessage, error) {
+	fields := map[string]json.RawMessage{}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	if string(data) == "null" {
+		return fields, nil
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// jsonOrNull returns the JSON value, null when it is missing.
+func jsonOrNull(value json.RawMessage) json.RawMessage {
+	if value == nil {
+		return json.RawMessage("null")
+	}
+	return value
+}
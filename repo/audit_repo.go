@@ -0,0 +1,70 @@
+package repo
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// AuditRepo is the AuditRepository backed by the database.
+type AuditRepo struct {
+	Db *Database
+}
+
+// NewAuditRepo initializes a new AuditRepo with a GORM instance.
+func NewAuditRepo(db *Database) *AuditRepo {
+	return &AuditRepo{db}
+}
+
+// FindAuditEvents retrieves the events matching the filters of the query, newest first.
+func (repo *AuditRepo) FindAuditEvents(ctx context.Context, query AuditQuery) ([]AuditEvent, error) {
+	db, cancel := repo.Db.WithContext(ctx)
+	defer cancel()
+
+	db = query.filter(db).Order("id DESC")
+	if query.Offset > 0 {
+		db = db.Offset(query.Offset)
+	}
+	if query.Limit > 0 {
+		db = db.Limit(query.Limit)
+	}
+
+	events := []AuditEvent{}
+	if err := db.Find(&events).Error; err != nil {
+		return nil, translateAuditError(err)
+	}
+	return events, nil
+}
+
+// CountAuditEvents returns the number of events matching the filters of the query,
+// ignoring its pagination.
+func (repo *AuditRepo) CountAuditEvents(ctx context.Context, query AuditQuery) (int64, error) {
+	db, cancel := repo.Db.WithContext(ctx)
+	defer cancel()
+
+	var count int64
+	if err := query.filter(db.Model(&AuditEvent{})).Count(&count).Error; err != nil {
+		return 0, translateAuditError(err)
+	}
+	return count, nil
+}
+
+// filter applies the filters of the query to an audit event query.
+func (q AuditQuery) filter(db *gorm.DB) *gorm.DB {
+	if q.Entity != "" {
+		db = db.Where("entity = ?", q.Entity)
+	}
+	if q.EntityID > 0 {
+		db = db.Where("entity_id = ?", q.EntityID)
+	}
+	return db
+}
+
+// recordStockAuditEvent writes the audit event of a stock mutation within its transaction.
+func recordStockAuditEvent(tx *gorm.DB, action string, before, after *Stock) error {
+	event, err := newStockAuditEvent(tx.Statement.Context, action, before, after)
+	if err != nil {
+		return err
+	}
+	return tx.Create(event).Error
+}
@@ -0,0 +1,100 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"stock-api/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStockAuditLog(t *testing.T) {
+	lastUpdate := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	for name, newRepos := range map[string]func(t *testing.T) (StockRepository, AuditRepository){
+		"memory": func(t *testing.T) (StockRepository, AuditRepository) {
+			repo := NewMemoryStockRepo()
+			return repo, repo
+		},
+		"sqlite": func(t *testing.T) (StockRepository, AuditRepository) {
+			db := newSQLiteDatabase(t)
+			return NewStockRepo(db), NewAuditRepo(db)
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := WithAuditInfo(context.Background(), AuditInfo{Actor: "alice", RequestID: "req-1"})
+			stocks, audit := newRepos(t)
+
+			// Mutate a stock in every audited way
+			apple := &Stock{Name: "Apple", Symbol: "AAPL", Exchange: "NASDAQ", CurrentPrice: util.MustParseDecimal("100.5"), LastUpdate: lastUpdate}
+			assert.NoError(t, stocks.CreateStock(ctx, apple))
+			assert.NoError(t, stocks.CreateStock(context.Background(), &Stock{Name: "Microsoft", Symbol: "MSFT", Exchange: "NASDAQ"}))
+
+			apple.CurrentPrice = util.MustParseDecimal("110")
+			assert.NoError(t, stocks.UpdateStock(ctx, apple))
+			assert.NoError(t, stocks.DeleteStock(ctx, uint(apple.ID)))
+			_, err := stocks.RestoreStock(ctx, uint(apple.ID))
+			assert.NoError(t, err)
+
+			// The events of the stock, newest first
+			query := AuditQuery{Entity: AuditEntityStock, EntityID: uint(apple.ID)}
+			events, err := audit.FindAuditEvents(ctx, query)
+			assert.NoError(t, err)
+
+			actions := []string{}
+			for _, event := range events {
+				actions = append(actions, event.Action)
+				assert.Equal(t, apple.ID, event.EntityID)
+				assert.Equal(t, "alice", event.Actor)
+				assert.Equal(t, "req-1", event.RequestID)
+			}
+			assert.Equal(t, []string{AuditActionRestore, AuditActionDelete, AuditActionUpdate, AuditActionCreate}, actions)
+
+			// Each event holds the fields it changed
+			update := events[2].Changes
+			assert.Len(t, update, 2)
+			assert.JSONEq(t, "100.5", string(update["currentPrice"].Before))
+			assert.JSONEq(t, "110", string(update["currentPrice"].After))
+			assert.JSONEq(t, "2", string(update["version"].After))
+
+			assert.JSONEq(t, "null", string(events[3].Changes["name"].Before))
+			assert.JSONEq(t, `"Apple"`, string(events[3].Changes["name"].After))
+
+			var deletedAt *time.Time
+			assert.NoError(t, json.Unmarshal(events[1].Changes["deletedAt"].After, &deletedAt))
+			assert.NotNil(t, deletedAt)
+			assert.JSONEq(t, "null", string(events[0].Changes["deletedAt"].After))
+
+			// Mutations made outside of a request are made by the system
+			all, err := audit.FindAuditEvents(ctx, AuditQuery{})
+			assert.NoError(t, err)
+			assert.Len(t, all, 5)
+			assert.Equal(t, AuditActorSystem, all[3].Actor)
+
+			count, err := audit.CountAuditEvents(ctx, query)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(4), count)
+
+			page, err := audit.FindAuditEvents(ctx, AuditQuery{Offset: 1, Limit: 2})
+			assert.NoError(t, err)
+			assert.Equal(t, []AuditEvent{all[1], all[2]}, page)
+
+			// Purging records an event per purged stock, made by the system
+			assert.NoError(t, stocks.DeleteStock(ctx, uint(apple.ID)))
+			purged, err := stocks.PurgeStocks(context.Background(), time.Now().Add(time.Second))
+			assert.NoError(t, err)
+			assert.Equal(t, int64(1), purged)
+
+			events, err = audit.FindAuditEvents(ctx, query)
+			assert.NoError(t, err)
+			assert.Len(t, events, 6)
+			assert.Equal(t, AuditActionPurge, events[0].Action)
+			assert.Equal(t, AuditActorSystem, events[0].Actor)
+			assert.JSONEq(t, `"Apple"`, string(events[0].Changes["name"].Before))
+			assert.JSONEq(t, "null", string(events[0].Changes["name"].After))
+		})
+	}
+}
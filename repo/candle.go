@@ -0,0 +1,138 @@
+package repo
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"stock-api/util"
+)
+
+// CandleInterval is the width of a candle bucket.
+type CandleInterval string
+
+const (
+	CandleInterval1m CandleInterval = "1m"
+	CandleInterval5m CandleInterval = "5m"
+	CandleInterval1h CandleInterval = "1h"
+	CandleInterval1d CandleInterval = "1d"
+)
+
+var candleDurations = map[CandleInterval]time.Duration{
+	CandleInterval1m: time.Minute,
+	CandleInterval5m: 5 * time.Minute,
+	CandleInterval1h: time.Hour,
+	CandleInterval1d: 24 * time.Hour,
+}
+
+// candleBuckets holds the Postgres expression truncating a price time to its bucket.
+// Buckets are truncated in UTC like AggregateCandles does, whatever the session time zone.
+var candleBuckets = map[CandleInterval]string{
+	CandleInterval1m: "date_trunc('minute', recorded_at AT TIME ZONE 'UTC') AT TIME ZONE 'UTC'",
+	CandleInterval5m: "(date_trunc('hour', recorded_at AT TIME ZONE 'UTC') + floor(date_part('minute', recorded_at AT TIME ZONE 'UTC') / 5) * interval '5 minutes') AT TIME ZONE 'UTC'",
+	CandleInterval1h: "date_trunc('hour', recorded_at AT TIME ZONE 'UTC') AT TIME ZONE 'UTC'",
+	CandleInterval1d: "date_trunc('day', recorded_at AT TIME ZONE 'UTC') AT TIME ZONE 'UTC'",
+}
+
+// Candle represents the open/high/low/close summary of the prices within a bucket.
+type Candle struct {
+	Start time.Time    `json:"start"`
+	Open  util.Decimal `json:"open" swaggertype:"number"`
+	High  util.Decimal `json:"high" swaggertype:"number"`
+	Low   util.Decimal `json:"low" swaggertype:"number"`
+	Close util.Decimal `json:"close" swaggertype:"number"`
+	Count int64        `json:"count"`
+}
+
+// ParseCandleInterval validates a candle interval such as "5m".
+func ParseCandleInterval(s string) (CandleInterval, error) {
+	interval := CandleInterval(s)
+	if _, ok := candleDurations[interval]; !ok {
+		return "", ErrInvalidCandleInterval
+	}
+	return interval, nil
+}
+
+// Duration returns the width of the interval.
+func (i CandleInterval) Duration() time.Duration {
+	return candleDurations[i]
+}
+
+// AggregateCandles groups prices into candles of the given interval, truncated in UTC.
+// Prices recorded at the same time are ordered by ID to pick the open and close.
+func AggregateCandles(prices []StockPrice, interval CandleInterval) []Candle {
+	candles := []Candle{}
+	width := interval.Duration()
+
+	sorted := append([]StockPrice(nil), prices...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if !sorted[i].RecordedAt.Equal(sorted[j].RecordedAt) {
+			return sorted[i].RecordedAt.Before(sorted[j].RecordedAt)
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	for _, price := range sorted {
+		start := price.RecordedAt.UTC().Truncate(width)
+
+		last := len(candles) - 1
+		if last < 0 || !candles[last].Start.Equal(start) {
+			candles = append(candles, Candle{
+				Start: start,
+				Open:  price.Price,
+				High:  price.Price,
+				Low:   price.Price,
+			})
+			last++
+		}
+
+		candle := &candles[last]
+		if price.Price.Cmp(candle.High) > 0 {
+			candle.High = price.Price
+		}
+		if price.Price.Cmp(candle.Low) < 0 {
+			candle.Low = price.Price
+		}
+		candle.Close = price.Price
+		candle.Count++
+	}
+
+	return candles
+}
+
+// GetStockCandles aggregates the recorded prices of a stock into candles.
+// A zero from or to leaves that side of the range open.
+// Postgres aggregates them in SQL, other databases in memory.
+func (repo *StockRepo) GetStockCandles(ctx context.Context, stockID uint, interval CandleInterval, from, to time.Time) ([]Candle, error) {
+	bucket, ok := candleBuckets[interval]
+	if !ok {
+		return nil, ErrInvalidCandleInterval
+	}
+
+	if repo.Db.db.Dialector.Name() != DriverPostgres {
+		prices, err := repo.GetStockPrices(ctx, stockID, from, to)
+		if err != nil {
+			return nil, err
+		}
+		return AggregateCandles(prices, interval), nil
+	}
+
+	db, cancel := repo.Db.WithContext(ctx)
+	defer cancel()
+
+	candles := []Candle{}
+	query := db.Model(&StockPrice{}).
+		Select(bucket+" AS start, "+
+			"(array_agg(price ORDER BY recorded_at ASC, id ASC))[1] AS open, "+
+			"MAX(price) AS high, "+
+			"MIN(price) AS low, "+
+			"(array_agg(price ORDER BY recorded_at DESC, id DESC))[1] AS close, "+
+			"COUNT(*) AS count").
+		Where("stock_id = ?", stockID)
+
+	result := filterPriceRange(query, from, to).Group("start").Order("start ASC").Scan(&candles)
+	if result.Error != nil {
+		return nil, translateStockError(result.Error)
+	}
+	return candles, nil
+}
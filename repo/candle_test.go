@@ -0,0 +1,62 @@
+package repo
+
+import (
+	"testing"
+	"time"
+
+	"stock-api/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCandleInterval(t *testing.T) {
+	interval, err := ParseCandleInterval("5m")
+	assert.NoError(t, err)
+	assert.Equal(t, CandleInterval5m, interval)
+	assert.Equal(t, 5*time.Minute, interval.Duration())
+
+	_, err = ParseCandleInterval("2m")
+	assert.ErrorIs(t, err, ErrInvalidCandleInterval)
+}
+
+func TestAggregateCandles(t *testing.T) {
+	// Define price ticks spread over two 5 minute buckets
+	start := time.Date(2023, 10, 1, 9, 0, 0, 0, time.UTC)
+	prices := []StockPrice{
+		{Price: util.MustParseDecimal("100.0"), RecordedAt: start},
+		{Price: util.MustParseDecimal("104.0"), RecordedAt: start.Add(1 * time.Minute)},
+		{Price: util.MustParseDecimal("98.0"), RecordedAt: start.Add(2 * time.Minute)},
+		{Price: util.MustParseDecimal("101.0"), RecordedAt: start.Add(4 * time.Minute)},
+		{Price: util.MustParseDecimal("102.0"), RecordedAt: start.Add(7 * time.Minute)},
+	}
+
+	// Call the function being tested
+	candles := AggregateCandles(prices, CandleInterval5m)
+
+	// Assert that the ticks were grouped into their buckets
+	assert.Equal(t, []Candle{
+		{Start: start, Open: util.MustParseDecimal("100.0"), High: util.MustParseDecimal("104.0"), Low: util.MustParseDecimal("98.0"), Close: util.MustParseDecimal("101.0"), Count: 4},
+		{Start: start.Add(5 * time.Minute), Open: util.MustParseDecimal("102.0"), High: util.MustParseDecimal("102.0"), Low: util.MustParseDecimal("102.0"), Close: util.MustParseDecimal("102.0"), Count: 1},
+	}, candles)
+}
+
+func TestAggregateCandlesTies(t *testing.T) {
+	// Ticks recorded at the same time, out of order, in another time zone
+	start := time.Date(2023, 10, 1, 23, 30, 0, 0, time.FixedZone("EST", -5*3600))
+	prices := []StockPrice{
+		{ID: 3, Price: util.MustParseDecimal("103.0"), RecordedAt: start},
+		{ID: 1, Price: util.MustParseDecimal("101.0"), RecordedAt: start},
+		{ID: 2, Price: util.MustParseDecimal("102.0"), RecordedAt: start},
+	}
+
+	// The open and close are picked by ID, the day bucket is truncated in UTC
+	candles := AggregateCandles(prices, CandleInterval1d)
+	assert.Equal(t, []Candle{
+		{Start: time.Date(2023, 10, 2, 0, 0, 0, 0, time.UTC), Open: util.MustParseDecimal("101.0"), High: util.MustParseDecimal("103.0"), Low: util.MustParseDecimal("101.0"), Close: util.MustParseDecimal("103.0"), Count: 3},
+	}, candles)
+	assert.Equal(t, uint(3), prices[0].ID, "the prices are left untouched")
+}
+
+func TestAggregateCandlesEmpty(t *testing.T) {
+	assert.Empty(t, AggregateCandles(nil, CandleInterval1d))
+}
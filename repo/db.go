@@ -1,20 +1,24 @@
 package repo
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/url"
 	"sync"
+	"time"
 
 	"stock-api/global"
 	"stock-api/util"
 
-	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
 type Database struct {
+	// Driver names the registered Dialect used to connect, postgres by default
+	Driver     string
+	DbPath     string
 	DbHost     string
 	DbPort     string
 	DbUsername string
@@ -23,6 +27,8 @@ type Database struct {
 	DbSSLMode  string
 	DbTimeZone string
 	LogLevel   logger.LogLevel // "gorm.io/gorm/logger"
+	// QueryTimeout bounds every query, zero means no timeout
+	QueryTimeout time.Duration
 
 	once sync.Once
 	db   *gorm.DB
@@ -43,21 +49,43 @@ func (d *Database) SetDB(db *gorm.DB) {
 	d.db = db
 }
 
+// WithContext returns a session bound to ctx and to the query timeout.
+// The returned cancel func must be called once the session is no longer used.
+func (d *Database) WithContext(ctx context.Context) (*gorm.DB, context.CancelFunc) {
+	cancel := context.CancelFunc(func() {})
+	if d.QueryTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, d.QueryTimeout)
+	}
+	return d.db.WithContext(ctx), cancel
+}
+
 // Connect creates a new gorm Db connection.
 func (d *Database) Connect() {
 	if d == nil {
 		log.Fatal("Db is nil")
 	}
 
-	ourDB, err := gorm.Open(postgres.Open(d.GetDns()), &gorm.Config{
+	driver := d.Driver
+	if driver == "" {
+		driver = DriverPostgres
+	}
+
+	dialect, err := GetDialect(driver)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ourDB, err := gorm.Open(dialect.Dialector(d), &gorm.Config{
 		Logger: logger.Default.LogMode(d.LogLevel),
+		// report unique constraint violations as gorm.ErrDuplicatedKey
+		TranslateError: true,
 	})
 
 	if err != nil || ourDB == nil {
 		log.Fatal(err)
 	} else {
-		fmt.Println("Yay! " + d.DbName + " Database Connected!")
-		fmt.Println("Database Host: " + d.DbHost)
+		fmt.Println("Yay! " + driver + " Database Connected!")
+		fmt.Println("Database: " + dialect.Describe(d))
 	}
 	d.SetDB(ourDB)
 }
@@ -93,6 +121,8 @@ func (db *Database) Setup(conf *global.VecConfig) {
 	}
 
 	// copy information
+	db.Driver = conf.DbDriver
+	db.DbPath = conf.DbPath
 	db.DbName = conf.DbName
 	db.DbHost = conf.DbHost
 	db.DbPort = conf.DbPort
@@ -100,6 +130,7 @@ func (db *Database) Setup(conf *global.VecConfig) {
 	db.DbPassword = conf.DbPassword
 	db.DbSSLMode = conf.DbSSLMode
 	db.DbTimeZone = conf.DbTimeZone
+	db.QueryTimeout = conf.DbQueryTimeout
 	db.LogLevel = logger.Silent
 	db.once = sync.Once{}
 
@@ -117,8 +148,22 @@ func dnsProcess(str string) string {
 	return url.QueryEscape(util.TrimSpaceToLower(str))
 }
 
+// Storage drivers selected by STORAGE_DRIVER.
+const (
+	StorageDriverDatabase = "database"
+	StorageDriverMemory   = "memory"
+)
+
 // Init initiate our repositories and database
 func Init() {
+	switch global.Config.StorageDriver {
+	case StorageDriverDatabase:
+	case StorageDriverMemory:
+		InitMemoryRepositories()
+		return
+	default:
+		log.Fatalf("unknown STORAGE_DRIVER %q", global.Config.StorageDriver)
+	}
 
 	// get configs from env
 	// set configs to Db
@@ -135,19 +180,51 @@ func Init() {
 func InitRepositories(db *Database) {
 	// Init Repositories
 	StockRepoInstance := NewStockRepo(db)
+	ApiKeyRepoInstance := NewApiKeyRepo(db)
+	FxRateRepoInstance := NewFxRateRepo(db)
+	AuditRepoInstance := NewAuditRepo(db)
 
 	// Init Server
-	Server = NewServer(StockRepoInstance)
+	Server = NewServer(StockRepoInstance, ApiKeyRepoInstance, FxRateRepoInstance, AuditRepoInstance)
 }
 
-func DoMigration() {
-	DBAutoMigration()
+// InitMemoryRepositories initiate in-memory repositories seeded with sample stocks,
+// no database is needed.
+func InitMemoryRepositories() {
+	StockRepoInstance := NewMemoryStockRepo()
+	for _, stock := range sampleStocks() {
+		if err := StockRepoInstance.CreateStock(context.Background(), &stock); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	Server = NewServer(StockRepoInstance, NewMemoryApiKeyRepo(), NewMemoryFxRateRepo(), StockRepoInstance)
 }
 
-func DBAutoMigration() {
-	_ = DB.DB().AutoMigrate(
-		&Stock{},
-	)
+// sampleStocks returns the sample data seeded by the first migration.
+func sampleStocks() []Stock {
+	now := time.Now()
+	return []Stock{
+		{Name: "Apple", Symbol: "AAPL", Exchange: "NASDAQ", Currency: "USD", ISIN: "US0378331005", CurrentPrice: util.MustParseDecimal("100.50"), LastUpdate: now},
+		{Name: "Microsoft", Symbol: "MSFT", Exchange: "NASDAQ", Currency: "USD", ISIN: "US5949181045", CurrentPrice: util.MustParseDecimal("75.25"), LastUpdate: now},
+		{Name: "Samsung", Symbol: "005930", Exchange: "KRX", Currency: "KRW", ISIN: "KR7005930003", CurrentPrice: util.MustParseDecimal("50.75"), LastUpdate: now},
+	}
+}
+
+// CheckMigrations stops the program when the database schema is not up to date.
+// Migrations are applied with the migrate up command, never on startup.
+func CheckMigrations() {
+	if global.Config.StorageDriver == StorageDriverMemory {
+		return
+	}
+
+	migrator, err := NewMigrator(DB.DB())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := migrator.Check(context.Background()); err != nil {
+		log.Fatalf("database schema is not up to date, run the migrate command: %v", err)
+	}
 }
 
 var DB = &Database{}
@@ -0,0 +1,67 @@
+package repo
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Database drivers selected by DB_DRIVER.
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// Dialect opens the connection of a database driver.
+type Dialect interface {
+	// Dialector returns the gorm dialector connecting to d.
+	Dialector(d *Database) gorm.Dialector
+	// Describe returns a human readable description of the connection to d.
+	Describe(d *Database) string
+}
+
+var dialects = map[string]Dialect{
+	DriverPostgres: postgresDialect{},
+	DriverSQLite:   sqliteDialect{},
+}
+
+// RegisterDialect makes a database driver selectable by name.
+func RegisterDialect(name string, dialect Dialect) {
+	dialects[name] = dialect
+}
+
+// GetDialect returns the dialect registered under name.
+func GetDialect(name string) (Dialect, error) {
+	dialect, ok := dialects[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown database driver %q", name)
+	}
+	return dialect, nil
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) Dialector(d *Database) gorm.Dialector {
+	return postgres.Open(d.GetDns())
+}
+
+func (postgresDialect) Describe(d *Database) string {
+	return fmt.Sprintf("%s on %s", d.DbName, d.DbHost)
+}
+
+// sqliteDialect opens a file backed database at DbPath.
+type sqliteDialect struct{}
+
+func (sqliteDialect) Dialector(d *Database) gorm.Dialector {
+	if d.DbPath == "" {
+		log.Fatal("DB_PATH must be set for the sqlite driver")
+	}
+	return sqlite.Open(d.DbPath + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)")
+}
+
+func (sqliteDialect) Describe(d *Database) string {
+	return d.DbPath
+}
@@ -1,7 +1,117 @@
 package repo
 
-import "errors"
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
 
 var (
-	ErrNilDatabase = errors.New("database is nil")
+	ErrNilDatabase           = errors.New("database is nil")
+	ErrInvalidCandleInterval = errors.New("invalid candle interval")
+	ErrInvalidSort           = errors.New("invalid sort")
+	ErrApiKeyNotFound        = errors.New("api key not found")
+	ErrFxRateNotFound        = errors.New("no fx rate")
+	ErrVersionConflict       = errors.New("stock was modified since the given version")
+	ErrInvalidMigration      = errors.New("invalid migration")
+	ErrPendingMigrations     = errors.New("pending migration")
+	ErrMigrationChecksum     = errors.New("applied migration was modified")
+	ErrUnknownMigration      = errors.New("applied migration is unknown")
+	ErrStockNotFound         = errors.New("stock not found")
+	ErrConflict              = errors.New("stock conflicts with an existing stock")
+	ErrUnavailable           = errors.New("database unavailable")
 )
+
+// translateStockError wraps the errors of the database driver into the errors
+// of StockRepository: ErrStockNotFound, ErrConflict or ErrUnavailable.
+// The driver error stays in the chain, other errors are returned as is.
+func translateStockError(err error) error {
+	switch {
+	case err == nil,
+		errors.Is(err, ErrStockNotFound), errors.Is(err, ErrConflict), errors.Is(err, ErrUnavailable):
+		return err
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return fmt.Errorf("%w: %w", ErrStockNotFound, err)
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return fmt.Errorf("%w: %w", ErrConflict, err)
+	case isUnavailable(err):
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+	return err
+}
+
+// translateApiKeyError wraps the errors of the database driver into the errors
+// of ApiKeyRepository: ErrApiKeyNotFound or ErrUnavailable.
+func translateApiKeyError(err error) error {
+	switch {
+	case err == nil, errors.Is(err, ErrApiKeyNotFound), errors.Is(err, ErrUnavailable):
+		return err
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return fmt.Errorf("%w: %w", ErrApiKeyNotFound, err)
+	case isUnavailable(err):
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+	return err
+}
+
+// translateFxError wraps the errors of the database driver telling that it can't
+// be reached into ErrUnavailable for FxRateRepository. A missing rate stays
+// gorm.ErrRecordNotFound.
+func translateFxError(err error) error {
+	if err != nil && !errors.Is(err, ErrUnavailable) && isUnavailable(err) {
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+	return err
+}
+
+// translateAuditError wraps the errors of the database driver telling that it can't
+// be reached into ErrUnavailable for AuditRepository.
+func translateAuditError(err error) error {
+	if err != nil && !errors.Is(err, ErrUnavailable) && isUnavailable(err) {
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+	return err
+}
+
+// isUnavailable reports whether err tells that the database can't be reached
+// or can't serve queries for now, rather than that the query is wrong.
+func isUnavailable(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) || pgconn.Timeout(err) {
+		return true
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return isUnavailableCode(pgErr.Code)
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return isUnavailableCode(string(pqErr.Code))
+	}
+	return false
+}
+
+// isUnavailableCode reports whether a PostgreSQL error code is a connection exception (08),
+// insufficient resources (53) or a shutdown of the server (57P01 to 57P03).
+func isUnavailableCode(code string) bool {
+	switch {
+	case strings.HasPrefix(code, "08"), strings.HasPrefix(code, "53"):
+		return true
+	case code == "57P01", code == "57P02", code == "57P03":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestTranslateStockError(t *testing.T) {
+	assert.NoError(t, translateStockError(nil))
+
+	other := errors.New("syntax error")
+	assert.Equal(t, other, translateStockError(other))
+	assert.Equal(t, ErrVersionConflict, translateStockError(ErrVersionConflict))
+
+	tests := []struct {
+		err  error
+		want error
+	}{
+		{gorm.ErrRecordNotFound, ErrStockNotFound},
+		{fmt.Errorf("first: %w", gorm.ErrRecordNotFound), ErrStockNotFound},
+		{gorm.ErrDuplicatedKey, ErrConflict},
+		{context.DeadlineExceeded, ErrUnavailable},
+		{&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, ErrUnavailable},
+		{&pgconn.PgError{Code: "08006"}, ErrUnavailable},
+		{&pgconn.PgError{Code: "53300"}, ErrUnavailable},
+		{&pq.Error{Code: "57P01"}, ErrUnavailable},
+		{&pq.Error{Code: "42P01"}, nil},
+	}
+	for _, test := range tests {
+		err := translateStockError(test.err)
+		assert.ErrorIs(t, err, test.err)
+		if test.want == nil {
+			assert.Equal(t, test.err, err)
+			continue
+		}
+		assert.ErrorIs(t, err, test.want)
+		// translating twice keeps a single sentinel
+		assert.Equal(t, err, translateStockError(err))
+	}
+}
+
+func TestTranslateFxError(t *testing.T) {
+	assert.NoError(t, translateFxError(nil))
+	assert.Equal(t, gorm.ErrRecordNotFound, translateFxError(gorm.ErrRecordNotFound))
+
+	err := translateFxError(&pgconn.PgError{Code: "08006"})
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.Equal(t, err, translateFxError(err))
+}
+
+func TestTranslateAuditError(t *testing.T) {
+	assert.NoError(t, translateAuditError(nil))
+
+	other := errors.New("syntax error")
+	assert.Equal(t, other, translateAuditError(other))
+
+	err := translateAuditError(context.DeadlineExceeded)
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.Equal(t, err, translateAuditError(err))
+}
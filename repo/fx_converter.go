@@ -0,0 +1,76 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"stock-api/util"
+
+	"gorm.io/gorm"
+)
+
+// FxConversion tells which rate a converted price was computed with.
+type FxConversion struct {
+	// From is the currency the stock is priced in
+	From string    `json:"from" example:"KRW"`
+	Rate util.Rate `json:"rate" swaggertype:"number" example:"0.00072"`
+	AsOf time.Time `json:"asOf"`
+}
+
+// FxConverter converts stock prices into another currency with the stored rates.
+type FxConverter struct {
+	Rates FxRateRepository
+}
+
+// NewFxConverter initializes a new FxConverter reading the rates from a repository.
+func NewFxConverter(rates FxRateRepository) *FxConverter {
+	return &FxConverter{Rates: rates}
+}
+
+// ConvertStocks converts the current price of the stocks into currency, and sets
+// their currency and the rate used. Stocks already priced in currency, or without
+// a currency, are left as is.
+// It fails with ErrFxRateNotFound when a needed rate isn't stored.
+func (c *FxConverter) ConvertStocks(ctx context.Context, stocks []Stock, currency string) error {
+	rates := map[string]*FxRate{}
+	for i := range stocks {
+		stock := &stocks[i]
+		if stock.Currency == "" || stock.Currency == currency {
+			continue
+		}
+
+		rate, ok := rates[stock.Currency]
+		if !ok {
+			var err error
+			rate, err = c.Rates.GetFxRate(ctx, stock.Currency, currency)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w from %s to %s", ErrFxRateNotFound, stock.Currency, currency)
+			}
+			if err != nil {
+				return err
+			}
+			rates[stock.Currency] = rate
+		}
+
+		price, err := stock.CurrentPrice.MulRate(rate.Rate)
+		if err != nil {
+			return err
+		}
+		stock.Fx = &FxConversion{From: stock.Currency, Rate: rate.Rate, AsOf: rate.AsOf}
+		stock.CurrentPrice = price
+		stock.Currency = currency
+	}
+	return nil
+}
+
+// ConvertStock converts a single stock like ConvertStocks.
+func (c *FxConverter) ConvertStock(ctx context.Context, stock *Stock, currency string) error {
+	stocks := []Stock{*stock}
+	if err := c.ConvertStocks(ctx, stocks, currency); err != nil {
+		return err
+	}
+	*stock = stocks[0]
+	return nil
+}
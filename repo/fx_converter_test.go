@@ -0,0 +1,96 @@
+package repo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"stock-api/util"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestFxConverter(t *testing.T) {
+	asOf := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	for name, newRepo := range map[string]func(t *testing.T) FxRateRepository{
+		"memory": func(t *testing.T) FxRateRepository { return NewMemoryFxRateRepo() },
+		"sqlite": func(t *testing.T) FxRateRepository { return NewFxRateRepo(newSQLiteDatabase(t)) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newRepo(t)
+
+			// Load a rate, then replace it
+			assert.NoError(t, repo.SaveFxRates(ctx, []FxRate{
+				{Base: "KRW", Quote: "USD", Rate: util.MustParseRate("0.0008"), AsOf: asOf.Add(-time.Hour)},
+				{Base: "EUR", Quote: "USD", Rate: util.MustParseRate("1.1"), AsOf: asOf},
+			}))
+			assert.NoError(t, repo.SaveFxRates(ctx, []FxRate{
+				{Base: "KRW", Quote: "USD", Rate: util.MustParseRate("0.00072"), AsOf: asOf},
+			}))
+
+			rates, err := repo.GetFxRates(ctx)
+			assert.NoError(t, err)
+			assert.Len(t, rates, 2)
+			assert.Equal(t, "EUR", rates[0].Base)
+
+			_, err = repo.GetFxRate(ctx, "USD", "KRW")
+			assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+			// Convert stocks priced in several currencies into USD
+			converter := NewFxConverter(repo)
+			stocks := []Stock{
+				{Name: "Samsung", Currency: "KRW", CurrentPrice: util.MustParseDecimal("71000")},
+				{Name: "Apple", Currency: "USD", CurrentPrice: util.MustParseDecimal("100.5")},
+				{Name: "Unknown", CurrentPrice: util.MustParseDecimal("10")},
+			}
+			assert.NoError(t, converter.ConvertStocks(ctx, stocks, "USD"))
+
+			assert.Equal(t, "51.12", stocks[0].CurrentPrice.String())
+			assert.Equal(t, "USD", stocks[0].Currency)
+			assert.Equal(t, "KRW", stocks[0].Fx.From)
+			assert.Equal(t, "0.00072", stocks[0].Fx.Rate.String())
+			assert.True(t, asOf.Equal(stocks[0].Fx.AsOf))
+
+			assert.Equal(t, "100.5", stocks[1].CurrentPrice.String())
+			assert.Nil(t, stocks[1].Fx)
+			assert.Equal(t, "10", stocks[2].CurrentPrice.String())
+			assert.Nil(t, stocks[2].Fx)
+
+			// Small rates are stored and applied with every significant digit
+			assert.NoError(t, repo.SaveFxRates(ctx, []FxRate{
+				{Base: "IDR", Quote: "USD", Rate: util.MustParseRate("0.00006143521"), AsOf: asOf},
+			}))
+			stock := Stock{Name: "Telkom", Currency: "IDR", CurrentPrice: util.MustParseDecimal("1000000")}
+			assert.NoError(t, converter.ConvertStock(ctx, &stock, "USD"))
+			assert.Equal(t, "61.43521", stock.CurrentPrice.String())
+			assert.Equal(t, "0.00006143521", stock.Fx.Rate.String())
+
+			// A missing rate fails the conversion
+			stock = Stock{Name: "Apple", Currency: "USD", CurrentPrice: util.MustParseDecimal("100.5")}
+			assert.ErrorIs(t, converter.ConvertStock(ctx, &stock, "KRW"), ErrFxRateNotFound)
+		})
+	}
+}
+
+func TestSQLiteFxRateRepo_Unavailable(t *testing.T) {
+	repo := NewFxRateRepo(newSQLiteDatabase(t))
+
+	// A query that times out isn't reported as a missing rate
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now())
+	defer cancel()
+
+	_, err := repo.GetFxRates(ctx)
+	assert.ErrorIs(t, err, ErrUnavailable)
+	_, err = repo.GetFxRate(ctx, "KRW", "USD")
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.NotErrorIs(t, err, gorm.ErrRecordNotFound)
+	assert.ErrorIs(t, repo.SaveFxRates(ctx, []FxRate{{Base: "KRW", Quote: "USD", Rate: util.MustParseRate("0.00072")}}), ErrUnavailable)
+
+	stock := Stock{Name: "Samsung", Currency: "KRW", CurrentPrice: util.MustParseDecimal("71000")}
+	err = NewFxConverter(repo).ConvertStock(ctx, &stock, "USD")
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.NotErrorIs(t, err, ErrFxRateNotFound)
+}
@@ -0,0 +1,88 @@
+package repo
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"stock-api/util"
+
+	"gorm.io/gorm/clause"
+)
+
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// IsCurrencyCode reports whether code is a normalized ISO 4217 code, e.g. USD.
+func IsCurrencyCode(code string) bool {
+	return currencyCode.MatchString(code)
+}
+
+// FxRate is the price of one unit of the Base currency in the Quote currency,
+// as of a given time. There is a single rate per currency pair.
+type FxRate struct {
+	Base  string    `gorm:"primaryKey" json:"base" example:"KRW"`
+	Quote string    `gorm:"primaryKey" json:"quote" example:"USD"`
+	Rate  util.Rate `json:"rate" swaggertype:"number" example:"0.00072"`
+	AsOf  time.Time `json:"asOf"`
+}
+
+// FxRateRepository stores the exchange rates used to convert prices.
+type FxRateRepository interface {
+	GetFxRates(ctx context.Context) ([]FxRate, error)
+	GetFxRate(ctx context.Context, base, quote string) (*FxRate, error)
+	SaveFxRates(ctx context.Context, rates []FxRate) error
+}
+
+// FxRateRepo is the FxRateRepository backed by the database.
+type FxRateRepo struct {
+	Db *Database
+}
+
+// NewFxRateRepo initializes a new FxRateRepo with a GORM instance.
+func NewFxRateRepo(db *Database) *FxRateRepo {
+	return &FxRateRepo{db}
+}
+
+// GetFxRates retrieves every exchange rate ordered by currency pair.
+func (repo *FxRateRepo) GetFxRates(ctx context.Context) ([]FxRate, error) {
+	db, cancel := repo.Db.WithContext(ctx)
+	defer cancel()
+
+	rates := []FxRate{}
+	if err := db.Order("base").Order("quote").Find(&rates).Error; err != nil {
+		return nil, translateFxError(err)
+	}
+	return rates, nil
+}
+
+// GetFxRate retrieves the exchange rate from base to quote,
+// gorm.ErrRecordNotFound when there is none, ErrUnavailable when the database
+// can't be reached.
+func (repo *FxRateRepo) GetFxRate(ctx context.Context, base, quote string) (*FxRate, error) {
+	db, cancel := repo.Db.WithContext(ctx)
+	defer cancel()
+
+	var rate FxRate
+	if err := db.Where("base = ? AND quote = ?", base, quote).First(&rate).Error; err != nil {
+		return nil, translateFxError(err)
+	}
+	return &rate, nil
+}
+
+// SaveFxRates inserts the rates, replacing the current rate of their currency pair.
+// Either every rate is saved or none is. The rates must be of distinct pairs,
+// Postgres can't replace a row twice in one statement.
+func (repo *FxRateRepo) SaveFxRates(ctx context.Context, rates []FxRate) error {
+	if len(rates) == 0 {
+		return nil
+	}
+
+	db, cancel := repo.Db.WithContext(ctx)
+	defer cancel()
+
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "base"}, {Name: "quote"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "as_of"}),
+	}).Create(&rates).Error
+	return translateFxError(err)
+}
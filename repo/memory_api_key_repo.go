@@ -0,0 +1,120 @@
+package repo
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MemoryApiKeyRepo is a thread-safe ApiKeyRepository keeping everything in memory.
+// It is meant for local development and tests.
+type MemoryApiKeyRepo struct {
+	mu     sync.RWMutex
+	lastID uint
+	keys   map[uint]ApiKey
+}
+
+// NewMemoryApiKeyRepo initializes an empty MemoryApiKeyRepo.
+func NewMemoryApiKeyRepo() *MemoryApiKeyRepo {
+	return &MemoryApiKeyRepo{keys: map[uint]ApiKey{}}
+}
+
+// CreateApiKey stores a new API key.
+func (m *MemoryApiKeyRepo) CreateApiKey(ctx context.Context, key *ApiKey) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.keys {
+		if existing.Prefix == key.Prefix {
+			return gorm.ErrDuplicatedKey
+		}
+	}
+
+	m.lastID++
+	key.ID = m.lastID
+	if key.CreatedAt.IsZero() {
+		key.CreatedAt = time.Now()
+	}
+	m.keys[key.ID] = *key
+	return nil
+}
+
+// GetApiKeys retrieves every API key ordered by ID.
+func (m *MemoryApiKeyRepo) GetApiKeys(ctx context.Context) ([]ApiKey, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	keys := make([]ApiKey, 0, len(m.keys))
+	for _, key := range m.keys {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].ID < keys[j].ID
+	})
+	return keys, nil
+}
+
+// GetApiKeyByPrefix retrieves a single API key by its public prefix.
+func (m *MemoryApiKeyRepo) GetApiKeyByPrefix(ctx context.Context, prefix string) (*ApiKey, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, key := range m.keys {
+		if key.Prefix == prefix {
+			return &key, nil
+		}
+	}
+	return nil, ErrApiKeyNotFound
+}
+
+// RevokeApiKey marks an API key as revoked.
+func (m *MemoryApiKeyRepo) RevokeApiKey(ctx context.Context, id uint) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key, ok := m.keys[id]
+	if !ok || key.RevokedAt != nil {
+		return ErrApiKeyNotFound
+	}
+
+	now := time.Now()
+	key.RevokedAt = &now
+	m.keys[id] = key
+	return nil
+}
+
+// TouchApiKey records the last time an API key was used.
+func (m *MemoryApiKeyRepo) TouchApiKey(ctx context.Context, id uint, usedAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if key, ok := m.keys[id]; ok {
+		key.LastUsedAt = &usedAt
+		m.keys[id] = key
+	}
+	return nil
+}
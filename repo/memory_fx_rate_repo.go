@@ -0,0 +1,75 @@
+package repo
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// MemoryFxRateRepo is a thread-safe FxRateRepository keeping everything in memory.
+// It is meant for local development and tests.
+type MemoryFxRateRepo struct {
+	mu    sync.RWMutex
+	rates map[[2]string]FxRate
+}
+
+// NewMemoryFxRateRepo initializes an empty MemoryFxRateRepo.
+func NewMemoryFxRateRepo() *MemoryFxRateRepo {
+	return &MemoryFxRateRepo{rates: map[[2]string]FxRate{}}
+}
+
+// GetFxRates retrieves every exchange rate ordered by currency pair.
+func (m *MemoryFxRateRepo) GetFxRates(ctx context.Context) ([]FxRate, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	rates := make([]FxRate, 0, len(m.rates))
+	for _, rate := range m.rates {
+		rates = append(rates, rate)
+	}
+
+	sort.Slice(rates, func(i, j int) bool {
+		if rates[i].Base != rates[j].Base {
+			return rates[i].Base < rates[j].Base
+		}
+		return rates[i].Quote < rates[j].Quote
+	})
+	return rates, nil
+}
+
+// GetFxRate retrieves the exchange rate from base to quote.
+func (m *MemoryFxRateRepo) GetFxRate(ctx context.Context, base, quote string) (*FxRate, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	rate, ok := m.rates[[2]string{base, quote}]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &rate, nil
+}
+
+// SaveFxRates stores the rates, replacing the current rate of their currency pair.
+func (m *MemoryFxRateRepo) SaveFxRates(ctx context.Context, rates []FxRate) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, rate := range rates {
+		m.rates[[2]string{rate.Base, rate.Quote}] = rate
+	}
+	return nil
+}
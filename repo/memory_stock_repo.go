@@ -0,0 +1,453 @@
+package repo
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"stock-api/util"
+
+	"gorm.io/gorm"
+)
+
+// MemoryStockRepo is a thread-safe StockRepository keeping everything in memory.
+// It is also the AuditRepository of its stock mutations.
+// It is meant for local development and tests.
+type MemoryStockRepo struct {
+	mu     sync.RWMutex
+	lastID uint
+	stocks map[uint]Stock
+	// deleted holds the soft deleted stocks until they are purged
+	deleted map[uint]Stock
+	prices  []StockPrice
+	// lastPriceID numbers the recorded prices like an auto increment column
+	lastPriceID uint
+	events      []AuditEvent
+}
+
+// NewMemoryStockRepo initializes an empty MemoryStockRepo.
+func NewMemoryStockRepo() *MemoryStockRepo {
+	return &MemoryStockRepo{stocks: map[uint]Stock{}, deleted: map[uint]Stock{}}
+}
+
+// CreateStock stores a new stock along with its initial price.
+// The ID is assigned like an auto increment column when it is zero.
+func (m *MemoryStockRepo) CreateStock(ctx context.Context, stock *Stock) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if stock.ID == 0 {
+		stock.ID = util.PublicID(m.lastID + 1)
+	}
+	id := uint(stock.ID)
+	_, live := m.stocks[id]
+	_, deleted := m.deleted[id]
+	if live || deleted || m.symbolTaken(stock) {
+		return ErrConflict
+	}
+
+	stock.Version = 1
+	event, err := newStockAuditEvent(ctx, AuditActionCreate, nil, stock)
+	if err != nil {
+		return err
+	}
+	if id > m.lastID {
+		m.lastID = id
+	}
+
+	m.stocks[id] = *stock
+	m.recordPrice(newStockPrice(stock, PriceSourceCreate))
+	m.recordEvent(event)
+	return nil
+}
+
+// GetStocks retrieves every stock ordered by ID.
+func (m *MemoryStockRepo) GetStocks(ctx context.Context) ([]Stock, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.sortedStocks(), nil
+}
+
+// GetStockByID retrieves a single stock by ID.
+func (m *MemoryStockRepo) GetStockByID(ctx context.Context, id uint) (*Stock, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stock, ok := m.stocks[id]
+	if !ok {
+		return nil, ErrStockNotFound
+	}
+	return &stock, nil
+}
+
+// UpdateStock replaces a stored stock. The stored stock must still be at the
+// version of stock, which is then incremented, otherwise it fails with ErrVersionConflict.
+// A new price record is written whenever the price changes.
+func (m *MemoryStockRepo) UpdateStock(ctx context.Context, stock *Stock) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := uint(stock.ID)
+	current, ok := m.stocks[id]
+	if !ok {
+		return ErrStockNotFound
+	}
+	if current.Version != stock.Version {
+		return ErrVersionConflict
+	}
+	if m.symbolTaken(stock) {
+		return ErrConflict
+	}
+
+	updated := *stock
+	updated.Version++
+	event, err := newStockAuditEvent(ctx, AuditActionUpdate, &current, &updated)
+	if err != nil {
+		return err
+	}
+	stock.Version = updated.Version
+
+	m.stocks[id] = *stock
+	if !current.CurrentPrice.Equal(stock.CurrentPrice) {
+		m.recordPrice(newStockPrice(stock, PriceSourceUpdate))
+	}
+	m.recordEvent(event)
+	return nil
+}
+
+// GetStockBySymbol retrieves a single stock by its exchange and symbol.
+func (m *MemoryStockRepo) GetStockBySymbol(ctx context.Context, exchange, symbol string) (*Stock, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, stock := range m.stocks {
+		if stock.Exchange == exchange && stock.Symbol == symbol {
+			return &stock, nil
+		}
+	}
+	return nil, ErrStockNotFound
+}
+
+// DeleteStock soft deletes a single stock, it can be restored until it is purged.
+// Deleting a missing stock is not an error.
+func (m *MemoryStockRepo) DeleteStock(ctx context.Context, id uint) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	before, ok := m.stocks[id]
+	if !ok {
+		return nil
+	}
+
+	stock := before
+	stock.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	event, err := newStockAuditEvent(ctx, AuditActionDelete, &before, &stock)
+	if err != nil {
+		return err
+	}
+
+	m.deleted[id] = stock
+	delete(m.stocks, id)
+	m.recordEvent(event)
+	return nil
+}
+
+// RestoreStock undoes the deletion of a single stock. It fails with
+// ErrStockNotFound when no deleted stock has the ID, and with
+// ErrConflict when another stock took its symbol meanwhile.
+func (m *MemoryStockRepo) RestoreStock(ctx context.Context, id uint) (*Stock, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	before, ok := m.deleted[id]
+	if !ok {
+		return nil, ErrStockNotFound
+	}
+	if m.symbolTaken(&before) {
+		return nil, ErrConflict
+	}
+
+	stock := before
+	stock.DeletedAt = gorm.DeletedAt{}
+	event, err := newStockAuditEvent(ctx, AuditActionRestore, &before, &stock)
+	if err != nil {
+		return nil, err
+	}
+
+	m.stocks[id] = stock
+	delete(m.deleted, id)
+	m.recordEvent(event)
+	return &stock, nil
+}
+
+// PurgeStocks permanently removes the stocks deleted before deletedBefore,
+// along with their price history, records an audit event per purged stock,
+// and returns how many were purged.
+func (m *MemoryStockRepo) PurgeStocks(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var stocks []Stock
+	for _, stock := range m.deleted {
+		if stock.DeletedAt.Time.Before(deletedBefore) {
+			stocks = append(stocks, stock)
+		}
+	}
+	if len(stocks) == 0 {
+		return 0, nil
+	}
+	sort.Slice(stocks, func(i, j int) bool { return stocks[i].ID < stocks[j].ID })
+
+	events := make([]*AuditEvent, len(stocks))
+	for i := range stocks {
+		event, err := newStockAuditEvent(ctx, AuditActionPurge, &stocks[i], nil)
+		if err != nil {
+			return 0, err
+		}
+		events[i] = event
+	}
+
+	purged := map[util.PublicID]bool{}
+	for i, stock := range stocks {
+		purged[stock.ID] = true
+		delete(m.deleted, uint(stock.ID))
+		m.recordEvent(events[i])
+	}
+
+	prices := m.prices[:0]
+	for _, price := range m.prices {
+		if !purged[price.StockID] {
+			prices = append(prices, price)
+		}
+	}
+	m.prices = prices
+	return int64(len(purged)), nil
+}
+
+// FindStocks retrieves the stocks matching the filters of the query, sorted and paginated.
+func (m *MemoryStockRepo) FindStocks(ctx context.Context, query StockQuery) ([]Stock, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return query.apply(m.queriedStocks(query)), nil
+}
+
+// CountStocks returns the number of stocks matching the filters of the query,
+// ignoring its sort and pagination.
+func (m *MemoryStockRepo) CountStocks(ctx context.Context, query StockQuery) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var count int64
+	for _, stock := range m.queriedStocks(query) {
+		if query.Matches(stock) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// SearchStocks ranks the stocks by trigram similarity of their name to q.
+func (m *MemoryStockRepo) SearchStocks(ctx context.Context, q string, limit int) ([]StockMatch, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return RankStocks(m.sortedStocks(), q, limit), nil
+}
+
+// GetStockPrices retrieves the recorded prices of a stock ordered by time, then by ID.
+// A zero from or to leaves that side of the range open.
+func (m *MemoryStockRepo) GetStockPrices(ctx context.Context, stockID uint, from, to time.Time) ([]StockPrice, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	prices := []StockPrice{}
+	for _, price := range m.prices {
+		if uint(price.StockID) != stockID {
+			continue
+		}
+		if !from.IsZero() && price.RecordedAt.Before(from) {
+			continue
+		}
+		if !to.IsZero() && price.RecordedAt.After(to) {
+			continue
+		}
+		prices = append(prices, price)
+	}
+
+	sort.SliceStable(prices, func(i, j int) bool {
+		if !prices[i].RecordedAt.Equal(prices[j].RecordedAt) {
+			return prices[i].RecordedAt.Before(prices[j].RecordedAt)
+		}
+		return prices[i].ID < prices[j].ID
+	})
+	return prices, nil
+}
+
+// GetStockCandles aggregates the recorded prices of a stock into candles.
+// A zero from or to leaves that side of the range open.
+func (m *MemoryStockRepo) GetStockCandles(ctx context.Context, stockID uint, interval CandleInterval, from, to time.Time) ([]Candle, error) {
+	if _, err := ParseCandleInterval(string(interval)); err != nil {
+		return nil, err
+	}
+
+	prices, err := m.GetStockPrices(ctx, stockID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return AggregateCandles(prices, interval), nil
+}
+
+// FindAuditEvents retrieves the audit events matching the filters of the query, newest first.
+func (m *MemoryStockRepo) FindAuditEvents(ctx context.Context, query AuditQuery) ([]AuditEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	events := []AuditEvent{}
+	for i := len(m.events) - 1; i >= 0; i-- {
+		if query.Matches(m.events[i]) {
+			events = append(events, m.events[i])
+		}
+	}
+
+	if query.Offset > 0 {
+		events = events[min(query.Offset, len(events)):]
+	}
+	if query.Limit > 0 && query.Limit < len(events) {
+		events = events[:query.Limit]
+	}
+	return events, nil
+}
+
+// CountAuditEvents returns the number of audit events matching the filters of the query,
+// ignoring its pagination.
+func (m *MemoryStockRepo) CountAuditEvents(ctx context.Context, query AuditQuery) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var count int64
+	for _, event := range m.events {
+		if query.Matches(event) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// recordPrice appends a price record, numbered like an auto increment column.
+// The caller must hold the lock.
+func (m *MemoryStockRepo) recordPrice(price *StockPrice) {
+	m.lastPriceID++
+	price.ID = m.lastPriceID
+	m.prices = append(m.prices, *price)
+}
+
+// recordEvent appends an audit event, numbered like an auto increment column.
+// The caller must hold the lock.
+func (m *MemoryStockRepo) recordEvent(event *AuditEvent) {
+	event.ID = uint(len(m.events) + 1)
+	m.events = append(m.events, *event)
+}
+
+// symbolTaken reports whether another stock has the symbol of stock on its exchange.
+// Stocks without a symbol never collide. The caller must hold the lock.
+func (m *MemoryStockRepo) symbolTaken(stock *Stock) bool {
+	if stock.Symbol == "" {
+		return false
+	}
+	for _, existing := range m.stocks {
+		if existing.ID != stock.ID && existing.Exchange == stock.Exchange && existing.Symbol == stock.Symbol {
+			return true
+		}
+	}
+	return false
+}
+
+// queriedStocks returns the stocks ordered by ID, along with the deleted ones
+// when the query includes them. The caller must hold the lock.
+func (m *MemoryStockRepo) queriedStocks(query StockQuery) []Stock {
+	stocks := m.sortedStocks()
+	if !query.IncludeDeleted || len(m.deleted) == 0 {
+		return stocks
+	}
+
+	for _, stock := range m.deleted {
+		stocks = append(stocks, stock)
+	}
+	sort.Slice(stocks, func(i, j int) bool {
+		return stocks[i].ID < stocks[j].ID
+	})
+	return stocks
+}
+
+// sortedStocks returns the stocks ordered by ID, without the deleted ones.
+// The caller must hold the lock.
+func (m *MemoryStockRepo) sortedStocks() []Stock {
+	stocks := make([]Stock, 0, len(m.stocks))
+	for _, stock := range m.stocks {
+		stocks = append(stocks, stock)
+	}
+
+	sort.Slice(stocks, func(i, j int) bool {
+		return stocks[i].ID < stocks[j].ID
+	})
+	return stocks
+}
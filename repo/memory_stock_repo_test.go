@@ -0,0 +1,178 @@
+package repo
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"stock-api/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newSeededMemoryRepo(t *testing.T, count int) *MemoryStockRepo {
+	repo := NewMemoryStockRepo()
+	for i := 0; i < count; i++ {
+		stock := &Stock{Name: "Stock", CurrentPrice: util.NewDecimalFromInt(int64(i))}
+		assert.NoError(t, repo.CreateStock(context.Background(), stock))
+	}
+	return repo
+}
+
+func TestMemoryStockRepo_CRUD(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryStockRepo()
+
+	// Create assigns increasing IDs
+	apple := &Stock{Name: "Apple", CurrentPrice: util.MustParseDecimal("100.0")}
+	assert.NoError(t, repo.CreateStock(ctx, apple))
+	assert.Equal(t, util.PublicID(1), apple.ID)
+
+	google := &Stock{Name: "Google", CurrentPrice: util.MustParseDecimal("150.0")}
+	assert.NoError(t, repo.CreateStock(ctx, google))
+	assert.Equal(t, util.PublicID(2), google.ID)
+
+	// Creating an existing ID fails
+	assert.ErrorIs(t, repo.CreateStock(ctx, &Stock{ID: 1}), ErrConflict)
+
+	// Get returns a copy of the stored stock
+	stock, err := repo.GetStockByID(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "Apple", stock.Name)
+
+	// Update replaces the stored stock
+	stock.CurrentPrice = util.MustParseDecimal("110.0")
+	assert.NoError(t, repo.UpdateStock(ctx, stock))
+	stock, err = repo.GetStockByID(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, util.MustParseDecimal("110.0"), stock.CurrentPrice)
+
+	assert.ErrorIs(t, repo.UpdateStock(ctx, &Stock{ID: 42}), ErrStockNotFound)
+
+	// Delete removes the stock
+	assert.NoError(t, repo.DeleteStock(ctx, 1))
+	_, err = repo.GetStockByID(ctx, 1)
+	assert.ErrorIs(t, err, ErrStockNotFound)
+
+	stocks, err := repo.GetStocks(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []Stock{*google}, stocks)
+}
+
+func TestMemoryStockRepo_CountStocks(t *testing.T) {
+	ctx := context.Background()
+	repo := newSeededMemoryRepo(t, 3)
+
+	count, err := repo.CountStocks(ctx, StockQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+
+	assert.NoError(t, repo.DeleteStock(ctx, 2))
+	count, err = repo.CountStocks(ctx, StockQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestMemoryStockRepo_PriceHistory(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryStockRepo()
+	start := time.Date(2023, 10, 1, 9, 0, 0, 0, time.UTC)
+
+	stock := &Stock{Name: "Apple", CurrentPrice: util.MustParseDecimal("100.0"), LastUpdate: start}
+	assert.NoError(t, repo.CreateStock(ctx, stock))
+
+	// Only price changes are recorded
+	stock.Name = "Apple Inc."
+	stock.LastUpdate = start.Add(time.Minute)
+	assert.NoError(t, repo.UpdateStock(ctx, stock))
+
+	stock.CurrentPrice = util.MustParseDecimal("105.0")
+	stock.LastUpdate = start.Add(2 * time.Minute)
+	assert.NoError(t, repo.UpdateStock(ctx, stock))
+
+	prices, err := repo.GetStockPrices(ctx, 1, time.Time{}, time.Time{})
+	assert.NoError(t, err)
+	assert.Len(t, prices, 2)
+	assert.Equal(t, PriceSourceCreate, prices[0].Source)
+	assert.Equal(t, util.MustParseDecimal("105.0"), prices[1].Price)
+
+	// The range is inclusive
+	prices, err = repo.GetStockPrices(ctx, 1, start.Add(2*time.Minute), time.Time{})
+	assert.NoError(t, err)
+	assert.Len(t, prices, 1)
+
+	candles, err := repo.GetStockCandles(ctx, 1, CandleInterval1h, time.Time{}, time.Time{})
+	assert.NoError(t, err)
+	assert.Equal(t, []Candle{{Start: start, Open: util.MustParseDecimal("100.0"), High: util.MustParseDecimal("105.0"), Low: util.MustParseDecimal("100.0"), Close: util.MustParseDecimal("105.0"), Count: 2}}, candles)
+}
+
+func TestMemoryStockRepo_GetStockPrices(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryStockRepo()
+	start := time.Date(2023, 10, 1, 9, 0, 0, 0, time.UTC)
+
+	apple := &Stock{Name: "Apple", CurrentPrice: util.MustParseDecimal("100.0"), LastUpdate: start}
+	assert.NoError(t, repo.CreateStock(ctx, apple))
+	google := &Stock{Name: "Google", CurrentPrice: util.MustParseDecimal("150.0"), LastUpdate: start}
+	assert.NoError(t, repo.CreateStock(ctx, google))
+
+	// Prices are recorded out of order
+	for _, update := range []struct {
+		price  string
+		minute int
+	}{{"103", 3}, {"101", 1}, {"102", 2}} {
+		apple.CurrentPrice = util.MustParseDecimal(update.price)
+		apple.LastUpdate = start.Add(time.Duration(update.minute) * time.Minute)
+		assert.NoError(t, repo.UpdateStock(ctx, apple))
+	}
+
+	prices := func(from, to time.Time) []string {
+		result, err := repo.GetStockPrices(ctx, 1, from, to)
+		assert.NoError(t, err)
+		values := []string{}
+		for _, price := range result {
+			assert.Equal(t, util.PublicID(1), price.StockID)
+			values = append(values, price.Price.String())
+		}
+		return values
+	}
+
+	// They are returned ordered by time, without the prices of other stocks
+	assert.Equal(t, []string{"100", "101", "102", "103"}, prices(time.Time{}, time.Time{}))
+
+	// Both limits of the range are inclusive, a zero limit leaves its side open
+	assert.Equal(t, []string{"101", "102"}, prices(start.Add(time.Minute), start.Add(2*time.Minute)))
+	assert.Equal(t, []string{"102", "103"}, prices(start.Add(2*time.Minute), time.Time{}))
+	assert.Equal(t, []string{"100", "101"}, prices(time.Time{}, start.Add(time.Minute)))
+	assert.Empty(t, prices(start.Add(time.Hour), time.Time{}))
+}
+
+func TestMemoryStockRepo_Concurrency(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryStockRepo()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, repo.CreateStock(ctx, &Stock{Name: "Stock"}))
+			_, err := repo.FindStocks(ctx, StockQuery{Limit: 10})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	stocks, err := repo.GetStocks(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, stocks, 50)
+}
+
+func TestMemoryStockRepo_CanceledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := NewMemoryStockRepo().GetStocks(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,188 @@
+package repo
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newEmptySQLiteDB(t *testing.T) *gorm.DB {
+	db := &Database{
+		Driver:   DriverSQLite,
+		DbPath:   filepath.Join(t.TempDir(), "stock.db"),
+		LogLevel: logger.Silent,
+	}
+	db.Connect()
+	t.Cleanup(db.Close)
+	return db.DB()
+}
+
+func testMigrations() fstest.MapFS {
+	return fstest.MapFS{
+		"0001_create_a.up.sql":   {Data: []byte("CREATE TABLE a (id INTEGER);")},
+		"0001_create_a.down.sql": {Data: []byte("DROP TABLE a;")},
+		"0002_create_b.up.sql":   {Data: []byte("CREATE TABLE b (id INTEGER);")},
+		"0002_create_b.down.sql": {Data: []byte("DROP TABLE b;")},
+	}
+}
+
+func TestMigrator_UpDownStatus(t *testing.T) {
+	ctx := context.Background()
+	db := newEmptySQLiteDB(t)
+
+	migrator, err := NewMigratorFS(db, testMigrations())
+	assert.NoError(t, err)
+
+	// Everything is pending on a new database
+	assert.ErrorIs(t, migrator.Check(ctx), ErrPendingMigrations)
+
+	statuses, err := migrator.Status(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, statuses, 2)
+	assert.Nil(t, statuses[0].AppliedAt)
+
+	// Up applies the migrations in order, once
+	applied, err := migrator.Up(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, applied, 2)
+	assert.Equal(t, int64(1), applied[0].Version)
+	assert.True(t, db.Migrator().HasTable("a"))
+	assert.True(t, db.Migrator().HasTable("b"))
+	assert.NoError(t, migrator.Check(ctx))
+
+	applied, err = migrator.Up(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, applied)
+
+	// Down reverts the latest migration only
+	reverted, err := migrator.Down(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "create_b", reverted.Name)
+	assert.True(t, db.Migrator().HasTable("a"))
+	assert.False(t, db.Migrator().HasTable("b"))
+	assert.ErrorIs(t, migrator.Check(ctx), ErrPendingMigrations)
+
+	_, err = migrator.Down(ctx)
+	assert.NoError(t, err)
+	reverted, err = migrator.Down(ctx)
+	assert.NoError(t, err)
+	assert.Nil(t, reverted)
+}
+
+func TestMigrator_BrokenMigrations(t *testing.T) {
+	ctx := context.Background()
+	db := newEmptySQLiteDB(t)
+
+	migrator, err := NewMigratorFS(db, testMigrations())
+	assert.NoError(t, err)
+	_, err = migrator.Up(ctx)
+	assert.NoError(t, err)
+
+	// An applied migration edited afterwards
+	edited := testMigrations()
+	edited["0001_create_a.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE a (id BIGINT);")}
+	migrator, err = NewMigratorFS(db, edited)
+	assert.NoError(t, err)
+	assert.ErrorIs(t, migrator.Check(ctx), ErrMigrationChecksum)
+	_, err = migrator.Up(ctx)
+	assert.ErrorIs(t, err, ErrMigrationChecksum)
+
+	// An applied migration missing from the binary
+	missing := testMigrations()
+	delete(missing, "0002_create_b.up.sql")
+	delete(missing, "0002_create_b.down.sql")
+	migrator, err = NewMigratorFS(db, missing)
+	assert.NoError(t, err)
+	assert.ErrorIs(t, migrator.Check(ctx), ErrUnknownMigration)
+}
+
+func TestMigrator_FailedMigrationIsRolledBack(t *testing.T) {
+	ctx := context.Background()
+	db := newEmptySQLiteDB(t)
+
+	files := testMigrations()
+	files["0003_broken.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE c (id INTEGER); INSERT INTO missing VALUES (1);")}
+	files["0003_broken.down.sql"] = &fstest.MapFile{Data: []byte("DROP TABLE c;")}
+
+	migrator, err := NewMigratorFS(db, files)
+	assert.NoError(t, err)
+
+	applied, err := migrator.Up(ctx)
+	assert.Error(t, err)
+	assert.Len(t, applied, 2)
+	assert.False(t, db.Migrator().HasTable("c"))
+	assert.ErrorIs(t, migrator.Check(ctx), ErrPendingMigrations)
+}
+
+func TestNewMigratorFS_InvalidFiles(t *testing.T) {
+	db := newEmptySQLiteDB(t)
+
+	for name, files := range map[string]fstest.MapFS{
+		"missing down": {"0001_a.up.sql": {Data: []byte("SELECT 1;")}},
+		"bad name":     {"init.sql": {Data: []byte("SELECT 1;")}},
+		"same version": {
+			"0001_a.up.sql":   {Data: []byte("SELECT 1;")},
+			"0001_a.down.sql": {Data: []byte("SELECT 1;")},
+			"0001_b.up.sql":   {Data: []byte("SELECT 1;")},
+			"0001_b.down.sql": {Data: []byte("SELECT 1;")},
+		},
+	} {
+		_, err := NewMigratorFS(db, files)
+		assert.ErrorIs(t, err, ErrInvalidMigration, name)
+	}
+}
+
+func TestEmbeddedMigrations_SQLite(t *testing.T) {
+	ctx := context.Background()
+	db := newEmptySQLiteDB(t)
+
+	migrator, err := NewMigrator(db)
+	assert.NoError(t, err)
+
+	// Up seeds the sample stocks with their initial price
+	_, err = migrator.Up(ctx)
+	assert.NoError(t, err)
+
+	stocks, err := NewStockRepo(&Database{db: db}).GetStocks(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, stocks, len(sampleStocks()))
+
+	prices, err := NewStockRepo(&Database{db: db}).GetStockPrices(ctx, uint(stocks[0].ID), time.Time{}, time.Time{})
+	assert.NoError(t, err)
+	assert.Len(t, prices, 1)
+
+	// The sample prices stored as REAL were converted to exact text
+	assert.Equal(t, "100.5", stocks[0].CurrentPrice.String())
+	assert.Equal(t, "100.5", prices[0].Price.String())
+
+	// The rates stored as REAL are converted to exact text as well
+	reverted, err := migrator.Down(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(11), reverted.Version)
+	assert.NoError(t, db.Exec("INSERT INTO fx_rates (base, quote, rate, as_of) VALUES ('KRW', 'USD', 0.00072, CURRENT_TIMESTAMP)").Error)
+	_, err = migrator.Up(ctx)
+	assert.NoError(t, err)
+
+	rate, err := NewFxRateRepo(&Database{db: db}).GetFxRate(ctx, "KRW", "USD")
+	assert.NoError(t, err)
+	assert.Equal(t, "0.00072", rate.Rate.String())
+
+	// Every migration can be reverted
+	for {
+		reverted, err := migrator.Down(ctx)
+		assert.NoError(t, err)
+		if err != nil || reverted == nil {
+			break
+		}
+	}
+	assert.False(t, db.Migrator().HasTable("stocks"))
+	assert.False(t, db.Migrator().HasTable("api_keys"))
+	assert.False(t, db.Migrator().HasTable("fx_rates"))
+	assert.False(t, db.Migrator().HasTable("audit_events"))
+}
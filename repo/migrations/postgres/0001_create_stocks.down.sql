@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS stock_prices;
+DROP TABLE IF EXISTS stocks;
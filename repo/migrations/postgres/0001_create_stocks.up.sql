@@ -0,0 +1,28 @@
+-- Create the stock table, seeded with sample data when empty
+CREATE TABLE IF NOT EXISTS stocks (
+    id BIGSERIAL PRIMARY KEY,
+    name TEXT,
+    current_price NUMERIC,
+    last_update TIMESTAMPTZ
+);
+
+INSERT INTO stocks (name, current_price, last_update)
+SELECT name, current_price, NOW()
+FROM (VALUES ('Apple', 100.50), ('Microsoft', 75.25), ('Samsung', 50.75)) AS sample (name, current_price)
+WHERE NOT EXISTS (SELECT 1 FROM stocks);
+
+-- Create the stock price history table
+CREATE TABLE IF NOT EXISTS stock_prices (
+    id BIGSERIAL PRIMARY KEY,
+    stock_id BIGINT NOT NULL,
+    price NUMERIC,
+    recorded_at TIMESTAMPTZ NOT NULL,
+    source TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_prices_stock_recorded ON stock_prices (stock_id, recorded_at);
+
+-- Record the initial price of stocks without history
+INSERT INTO stock_prices (stock_id, price, recorded_at, source)
+SELECT id, current_price, COALESCE(last_update, NOW()), 'create' FROM stocks
+WHERE NOT EXISTS (SELECT 1 FROM stock_prices WHERE stock_prices.stock_id = stocks.id);
@@ -0,0 +1,14 @@
+-- Create the api key table, only the hash of the key secret is stored
+CREATE TABLE IF NOT EXISTS api_keys (
+    id BIGSERIAL PRIMARY KEY,
+    prefix TEXT NOT NULL,
+    secret_hash TEXT NOT NULL,
+    owner TEXT NOT NULL,
+    scopes TEXT,
+    expires_at TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_keys_prefix ON api_keys (prefix);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_stocks_exchange_symbol;
+
+ALTER TABLE stocks DROP COLUMN isin;
+ALTER TABLE stocks DROP COLUMN currency;
+ALTER TABLE stocks DROP COLUMN exchange;
+ALTER TABLE stocks DROP COLUMN symbol;
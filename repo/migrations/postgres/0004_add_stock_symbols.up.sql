@@ -0,0 +1,13 @@
+-- Identify stocks by their symbol on an exchange
+ALTER TABLE stocks ADD COLUMN symbol TEXT NOT NULL DEFAULT '';
+ALTER TABLE stocks ADD COLUMN exchange TEXT NOT NULL DEFAULT '';
+ALTER TABLE stocks ADD COLUMN currency TEXT NOT NULL DEFAULT '';
+ALTER TABLE stocks ADD COLUMN isin TEXT NOT NULL DEFAULT '';
+
+-- Identify the sample data
+UPDATE stocks SET symbol = 'AAPL', exchange = 'NASDAQ', currency = 'USD', isin = 'US0378331005' WHERE name = 'Apple';
+UPDATE stocks SET symbol = 'MSFT', exchange = 'NASDAQ', currency = 'USD', isin = 'US5949181045' WHERE name = 'Microsoft';
+UPDATE stocks SET symbol = '005930', exchange = 'KRX', currency = 'KRW', isin = 'KR7005930003' WHERE name = 'Samsung';
+
+-- Stocks created before symbols existed have none and don't collide
+CREATE UNIQUE INDEX idx_stocks_exchange_symbol ON stocks (exchange, symbol) WHERE symbol <> '';
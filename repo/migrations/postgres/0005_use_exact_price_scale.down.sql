@@ -0,0 +1,2 @@
+ALTER TABLE stock_prices ALTER COLUMN price TYPE NUMERIC;
+ALTER TABLE stocks ALTER COLUMN current_price TYPE NUMERIC;
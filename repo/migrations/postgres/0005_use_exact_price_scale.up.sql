@@ -0,0 +1,3 @@
+-- Store prices with the exact scale of util.Decimal
+ALTER TABLE stocks ALTER COLUMN current_price TYPE NUMERIC(19, 8);
+ALTER TABLE stock_prices ALTER COLUMN price TYPE NUMERIC(19, 8);
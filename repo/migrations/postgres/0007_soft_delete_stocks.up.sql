@@ -0,0 +1,7 @@
+-- Keep deleted stocks until they are purged
+ALTER TABLE stocks ADD COLUMN deleted_at TIMESTAMPTZ;
+CREATE INDEX idx_stocks_deleted_at ON stocks (deleted_at);
+
+-- Deleted stocks free their symbol
+DROP INDEX IF EXISTS idx_stocks_exchange_symbol;
+CREATE UNIQUE INDEX idx_stocks_exchange_symbol ON stocks (exchange, symbol) WHERE symbol <> '' AND deleted_at IS NULL;
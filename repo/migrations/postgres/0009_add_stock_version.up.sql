@@ -0,0 +1,2 @@
+-- Count the updates of each stock for optimistic concurrency control
+ALTER TABLE stocks ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
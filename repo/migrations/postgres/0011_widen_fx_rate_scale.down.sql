@@ -0,0 +1 @@
+ALTER TABLE fx_rates ALTER COLUMN rate TYPE NUMERIC(19, 8);
@@ -0,0 +1,2 @@
+-- Store rates with the exact scale of util.Rate, small rates lose their digits at scale 8
+ALTER TABLE fx_rates ALTER COLUMN rate TYPE NUMERIC(38, 18);
@@ -0,0 +1,28 @@
+-- Create the stock table, seeded with sample data when empty
+CREATE TABLE IF NOT EXISTS stocks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT,
+    current_price REAL,
+    last_update DATETIME
+);
+
+INSERT INTO stocks (name, current_price, last_update)
+SELECT column1, column2, strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')
+FROM (VALUES ('Apple', 100.50), ('Microsoft', 75.25), ('Samsung', 50.75))
+WHERE NOT EXISTS (SELECT 1 FROM stocks);
+
+-- Create the stock price history table
+CREATE TABLE IF NOT EXISTS stock_prices (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    stock_id INTEGER NOT NULL,
+    price REAL,
+    recorded_at DATETIME NOT NULL,
+    source TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_prices_stock_recorded ON stock_prices (stock_id, recorded_at);
+
+-- Record the initial price of stocks without history
+INSERT INTO stock_prices (stock_id, price, recorded_at, source)
+SELECT id, current_price, COALESCE(last_update, strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')), 'create' FROM stocks
+WHERE NOT EXISTS (SELECT 1 FROM stock_prices WHERE stock_prices.stock_id = stocks.id);
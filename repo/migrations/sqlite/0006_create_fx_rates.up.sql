@@ -0,0 +1,8 @@
+-- Create the exchange rate table, one rate per currency pair
+CREATE TABLE IF NOT EXISTS fx_rates (
+    base TEXT NOT NULL,
+    quote TEXT NOT NULL,
+    rate REAL NOT NULL,
+    as_of DATETIME NOT NULL,
+    PRIMARY KEY (base, quote)
+);
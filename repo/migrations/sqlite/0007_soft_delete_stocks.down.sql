@@ -0,0 +1,9 @@
+-- Purge the deleted stocks rather than bringing them back
+DELETE FROM stock_prices WHERE stock_id IN (SELECT id FROM stocks WHERE deleted_at IS NOT NULL);
+DELETE FROM stocks WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_stocks_exchange_symbol;
+CREATE UNIQUE INDEX idx_stocks_exchange_symbol ON stocks (exchange, symbol) WHERE symbol <> '';
+
+DROP INDEX IF EXISTS idx_stocks_deleted_at;
+ALTER TABLE stocks DROP COLUMN deleted_at;
@@ -0,0 +1,9 @@
+ALTER TABLE stock_prices ADD COLUMN price_real REAL;
+UPDATE stock_prices SET price_real = CAST(price AS REAL);
+ALTER TABLE stock_prices DROP COLUMN price;
+ALTER TABLE stock_prices RENAME COLUMN price_real TO price;
+
+ALTER TABLE stocks ADD COLUMN current_price_real REAL;
+UPDATE stocks SET current_price_real = CAST(current_price AS REAL);
+ALTER TABLE stocks DROP COLUMN current_price;
+ALTER TABLE stocks RENAME COLUMN current_price_real TO current_price;
@@ -0,0 +1,10 @@
+-- Store prices as the exact text of util.Decimal, REAL rounds them to binary floats
+ALTER TABLE stocks ADD COLUMN current_price_text TEXT;
+UPDATE stocks SET current_price_text = rtrim(rtrim(printf('%.8f', current_price), '0'), '.') WHERE current_price IS NOT NULL;
+ALTER TABLE stocks DROP COLUMN current_price;
+ALTER TABLE stocks RENAME COLUMN current_price_text TO current_price;
+
+ALTER TABLE stock_prices ADD COLUMN price_text TEXT;
+UPDATE stock_prices SET price_text = rtrim(rtrim(printf('%.8f', price), '0'), '.') WHERE price IS NOT NULL;
+ALTER TABLE stock_prices DROP COLUMN price;
+ALTER TABLE stock_prices RENAME COLUMN price_text TO price;
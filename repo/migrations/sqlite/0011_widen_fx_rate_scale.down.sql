@@ -0,0 +1,11 @@
+CREATE TABLE fx_rates_real (
+    base TEXT NOT NULL,
+    quote TEXT NOT NULL,
+    rate REAL NOT NULL,
+    as_of DATETIME NOT NULL,
+    PRIMARY KEY (base, quote)
+);
+INSERT INTO fx_rates_real (base, quote, rate, as_of)
+SELECT base, quote, CAST(rate AS REAL), as_of FROM fx_rates;
+DROP TABLE fx_rates;
+ALTER TABLE fx_rates_real RENAME TO fx_rates;
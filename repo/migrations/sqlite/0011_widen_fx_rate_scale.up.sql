@@ -0,0 +1,13 @@
+-- Store rates as the exact text of util.Rate, REAL rounds them to binary floats.
+-- The table is rebuilt as a NOT NULL column can't be added without a default.
+CREATE TABLE fx_rates_text (
+    base TEXT NOT NULL,
+    quote TEXT NOT NULL,
+    rate TEXT NOT NULL,
+    as_of DATETIME NOT NULL,
+    PRIMARY KEY (base, quote)
+);
+INSERT INTO fx_rates_text (base, quote, rate, as_of)
+SELECT base, quote, rtrim(rtrim(printf('%.8f', rate), '0'), '.'), as_of FROM fx_rates;
+DROP TABLE fx_rates;
+ALTER TABLE fx_rates_text RENAME TO fx_rates;
@@ -0,0 +1,37 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockApiKeyRepo struct {
+	mock.Mock
+}
+
+func (m *MockApiKeyRepo) CreateApiKey(ctx context.Context, key *ApiKey) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+func (m *MockApiKeyRepo) GetApiKeys(ctx context.Context) ([]ApiKey, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]ApiKey), args.Error(1)
+}
+
+func (m *MockApiKeyRepo) GetApiKeyByPrefix(ctx context.Context, prefix string) (*ApiKey, error) {
+	args := m.Called(ctx, prefix)
+	return args.Get(0).(*ApiKey), args.Error(1)
+}
+
+func (m *MockApiKeyRepo) RevokeApiKey(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockApiKeyRepo) TouchApiKey(ctx context.Context, id uint, usedAt time.Time) error {
+	args := m.Called(ctx, id, usedAt)
+	return args.Error(0)
+}
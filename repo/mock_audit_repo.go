@@ -0,0 +1,21 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAuditRepo struct {
+	mock.Mock
+}
+
+func (m *MockAuditRepo) FindAuditEvents(ctx context.Context, query AuditQuery) ([]AuditEvent, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).([]AuditEvent), args.Error(1)
+}
+
+func (m *MockAuditRepo) CountAuditEvents(ctx context.Context, query AuditQuery) (int64, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).(int64), args.Error(1)
+}
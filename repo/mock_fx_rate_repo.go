@@ -0,0 +1,26 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockFxRateRepo struct {
+	mock.Mock
+}
+
+func (m *MockFxRateRepo) GetFxRates(ctx context.Context) ([]FxRate, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]FxRate), args.Error(1)
+}
+
+func (m *MockFxRateRepo) GetFxRate(ctx context.Context, base, quote string) (*FxRate, error) {
+	args := m.Called(ctx, base, quote)
+	return args.Get(0).(*FxRate), args.Error(1)
+}
+
+func (m *MockFxRateRepo) SaveFxRates(ctx context.Context, rates []FxRate) error {
+	args := m.Called(ctx, rates)
+	return args.Error(0)
+}
@@ -1,6 +1,9 @@
 package repo
 
 import (
+	"context"
+	"time"
+
 	"github.com/stretchr/testify/mock"
 )
 
@@ -8,32 +11,67 @@ type MockStockRepo struct {
 	mock.Mock
 }
 
-func (m *MockStockRepo) CreateStock(stock *Stock) error {
-	args := m.Called(stock)
+func (m *MockStockRepo) CreateStock(ctx context.Context, stock *Stock) error {
+	args := m.Called(ctx, stock)
 	return args.Error(0)
 }
 
-func (m *MockStockRepo) GetStocks() ([]Stock, error) {
-	args := m.Called()
+func (m *MockStockRepo) GetStocks(ctx context.Context) ([]Stock, error) {
+	args := m.Called(ctx)
 	return args.Get(0).([]Stock), args.Error(1)
 }
 
-func (m *MockStockRepo) GetStockByID(id uint) (*Stock, error) {
-	args := m.Called(id)
+func (m *MockStockRepo) GetStockByID(ctx context.Context, id uint) (*Stock, error) {
+	args := m.Called(ctx, id)
 	return args.Get(0).(*Stock), args.Error(1)
 }
 
-func (m *MockStockRepo) UpdateStock(stock *Stock) error {
-	args := m.Called(stock)
+func (m *MockStockRepo) GetStockBySymbol(ctx context.Context, exchange, symbol string) (*Stock, error) {
+	args := m.Called(ctx, exchange, symbol)
+	return args.Get(0).(*Stock), args.Error(1)
+}
+
+func (m *MockStockRepo) UpdateStock(ctx context.Context, stock *Stock) error {
+	args := m.Called(ctx, stock)
 	return args.Error(0)
 }
 
-func (m *MockStockRepo) DeleteStock(id uint) error {
-	args := m.Called(id)
+func (m *MockStockRepo) DeleteStock(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
-func (m *MockStockRepo) GetPaginatedStocks(page, pageSize int) ([]Stock, error) {
-	args := m.Called(page, pageSize)
+func (m *MockStockRepo) RestoreStock(ctx context.Context, id uint) (*Stock, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*Stock), args.Error(1)
+}
+
+func (m *MockStockRepo) PurgeStocks(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	args := m.Called(ctx, deletedBefore)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockStockRepo) FindStocks(ctx context.Context, query StockQuery) ([]Stock, error) {
+	args := m.Called(ctx, query)
 	return args.Get(0).([]Stock), args.Error(1)
 }
+
+func (m *MockStockRepo) CountStocks(ctx context.Context, query StockQuery) (int64, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockStockRepo) SearchStocks(ctx context.Context, q string, limit int) ([]StockMatch, error) {
+	args := m.Called(ctx, q, limit)
+	return args.Get(0).([]StockMatch), args.Error(1)
+}
+
+func (m *MockStockRepo) GetStockPrices(ctx context.Context, stockID uint, from, to time.Time) ([]StockPrice, error) {
+	args := m.Called(ctx, stockID, from, to)
+	return args.Get(0).([]StockPrice), args.Error(1)
+}
+
+func (m *MockStockRepo) GetStockCandles(ctx context.Context, stockID uint, interval CandleInterval, from, to time.Time) ([]Candle, error) {
+	args := m.Called(ctx, stockID, interval, from, to)
+	return args.Get(0).([]Candle), args.Error(1)
+}
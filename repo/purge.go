@@ -0,0 +1,29 @@
+package repo
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// PurgeDeletedStocks permanently deletes the stocks deleted for longer than
+// retention, right away then every interval, until ctx is done.
+func PurgeDeletedStocks(ctx context.Context, stocks StockRepository, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := stocks.PurgeStocks(ctx, time.Now().Add(-retention))
+		if err != nil {
+			log.Println("Error while purging deleted stocks:", err)
+		} else if purged > 0 {
+			log.Printf("Purged %d stocks deleted more than %s ago", purged, retention)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
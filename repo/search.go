@@ -0,0 +1,177 @@
+package repo
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm/clause"
+)
+
+// Trigram match thresholds, the defaults of the Postgres pg_trgm extension.
+const (
+	similarityThreshold     = 0.3
+	wordSimilarityThreshold = 0.6
+)
+
+// maxSearchCandidates bounds the stocks ranked in memory by a search.
+var maxSearchCandidates = 1000
+
+// StockMatch is a stock found by a search, scored between 0 and 1.
+type StockMatch struct {
+	Stock
+	Score float64 `json:"score"`
+}
+
+// SearchStocks ranks the stocks by trigram similarity of their name to q.
+// Postgres ranks them with pg_trgm, other databases in memory: only the stocks
+// whose name shares a trigram with q are loaded, at most maxSearchCandidates
+// of those sharing the most.
+func (repo *StockRepo) SearchStocks(ctx context.Context, q string, limit int) ([]StockMatch, error) {
+	db, cancel := repo.Db.WithContext(ctx)
+	defer cancel()
+
+	if db.Dialector.Name() != DriverPostgres {
+		patterns := searchPatterns(q)
+		if len(patterns) == 0 {
+			return []StockMatch{}, nil
+		}
+
+		conditions := make([]string, len(patterns))
+		matched := make([]string, len(patterns))
+		args := make([]interface{}, len(patterns))
+		for i, pattern := range patterns {
+			conditions[i] = `LOWER(name) LIKE ? ESCAPE '\'`
+			matched[i] = "CASE WHEN " + conditions[i] + " THEN 1 ELSE 0 END"
+			args[i] = pattern
+		}
+
+		// Keep the candidates matching the most patterns when there are too many
+		order := clause.OrderBy{Expression: clause.Expr{
+			SQL:                "(" + strings.Join(matched, " + ") + ") DESC, id",
+			Vars:               args,
+			WithoutParentheses: true,
+		}}
+
+		var stocks []Stock
+		err := db.Where(strings.Join(conditions, " OR "), args...).Clauses(order).Limit(maxSearchCandidates).Find(&stocks).Error
+		if err != nil {
+			return nil, translateStockError(err)
+		}
+		return RankStocks(stocks, q, limit), nil
+	}
+
+	matches := []StockMatch{}
+	result := db.Model(&Stock{}).
+		Select("*, GREATEST(similarity(name, ?), word_similarity(?, name)) AS score", q, q).
+		Where("name % ? OR ? <% name", q, q).
+		Order("score DESC").Order("id").
+		Limit(limit).
+		Scan(&matches)
+	if result.Error != nil {
+		return nil, translateStockError(result.Error)
+	}
+	return matches, nil
+}
+
+// RankStocks scores the names of the stocks against q like pg_trgm does, and
+// returns at most limit matching stocks, best first.
+func RankStocks(stocks []Stock, q string, limit int) []StockMatch {
+	query := trigrams(q)
+
+	matches := []StockMatch{}
+	for _, stock := range stocks {
+		name := trigrams(stock.Name)
+		similarity := trigramSimilarity(query, name)
+		wordSimilarity := trigramCoverage(query, name)
+		if similarity < similarityThreshold && wordSimilarity < wordSimilarityThreshold {
+			continue
+		}
+		matches = append(matches, StockMatch{Stock: stock, Score: max(similarity, wordSimilarity)})
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].ID < matches[j].ID
+	})
+
+	if limit > 0 && limit < len(matches) {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// searchPatterns returns the LIKE patterns matching the names that share a trigram
+// with q, ignoring the padding: every 3 letters of its words, or its shorter words.
+func searchPatterns(q string) []string {
+	seen := map[string]bool{}
+	patterns := []string{}
+	add := func(part string) {
+		if !seen[part] {
+			seen[part] = true
+			patterns = append(patterns, "%"+escapeLike(part)+"%")
+		}
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		runes := []rune(word)
+		if len(runes) < 3 {
+			add(word)
+			continue
+		}
+		for i := 0; i+3 <= len(runes); i++ {
+			add(string(runes[i : i+3]))
+		}
+	}
+	return patterns
+}
+
+// trigrams returns the set of trigrams of the words of s, lower cased and
+// padded with two spaces in front and one behind like pg_trgm.
+func trigrams(s string) map[string]struct{} {
+	set := map[string]struct{}{}
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			set[string(padded[i:i+3])] = struct{}{}
+		}
+	}
+	return set
+}
+
+// trigramSimilarity is the share of trigrams two strings have in common.
+func trigramSimilarity(a, b map[string]struct{}) float64 {
+	common := commonTrigrams(a, b)
+	if total := len(a) + len(b) - common; total > 0 {
+		return float64(common) / float64(total)
+	}
+	return 0
+}
+
+// trigramCoverage is the share of the trigrams of a found in b, an
+// approximation of pg_trgm word_similarity matching a against part of b.
+func trigramCoverage(a, b map[string]struct{}) float64 {
+	if len(a) == 0 {
+		return 0
+	}
+	return float64(commonTrigrams(a, b)) / float64(len(a))
+}
+
+func commonTrigrams(a, b map[string]struct{}) int {
+	common := 0
+	for trigram := range a {
+		if _, ok := b[trigram]; ok {
+			common++
+		}
+	}
+	return common
+}
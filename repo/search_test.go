@@ -0,0 +1,106 @@
+package repo
+
+import (
+	"context"
+	"testing"
+
+	"stock-api/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRankStocks(t *testing.T) {
+	stocks := sampleStocks()
+	for i := range stocks {
+		stocks[i].ID = util.PublicID(i + 1)
+	}
+	stocks = append(stocks, Stock{ID: 4, Name: "Micron Technology"})
+
+	names := func(matches []StockMatch) []string {
+		result := []string{}
+		for _, match := range matches {
+			result = append(result, match.Name)
+		}
+		return result
+	}
+
+	for _, tc := range []struct {
+		q        string
+		expected []string
+	}{
+		// partial names
+		{"micro", []string{"Microsoft", "Micron Technology"}},
+		{"APP", []string{"Apple"}},
+		// typos
+		{"samsng", []string{"Samsung"}},
+		{"mircosoft", []string{"Microsoft"}},
+		// unrelated text
+		{"tesla", []string{}},
+		{"!!", []string{}},
+	} {
+		assert.Equal(t, tc.expected, names(RankStocks(stocks, tc.q, 10)), tc.q)
+	}
+
+	// Exact names score best, and the limit keeps the best matches
+	matches := RankStocks(stocks, "microsoft", 1)
+	assert.Equal(t, []string{"Microsoft"}, names(matches))
+	assert.Equal(t, 1.0, matches[0].Score)
+}
+
+func TestSearchPatterns(t *testing.T) {
+	assert.Equal(t, []string{"%sam%", "%ams%", "%msn%", "%sng%", "%co%"}, searchPatterns("Samsng, Co."))
+	assert.Equal(t, []string{"%a%"}, searchPatterns("a a"))
+	assert.Empty(t, searchPatterns("!% _"))
+}
+
+func TestSearchStocksCandidates(t *testing.T) {
+	ctx := context.Background()
+	repo := NewStockRepo(newSQLiteDatabase(t))
+
+	// Only two candidates are ranked, the best match has the highest ID
+	limit := maxSearchCandidates
+	maxSearchCandidates = 2
+	t.Cleanup(func() { maxSearchCandidates = limit })
+
+	for _, name := range []string{"Sam One", "Sam Two", "Samsung"} {
+		assert.NoError(t, repo.CreateStock(ctx, &Stock{Name: name}))
+	}
+
+	matches, err := repo.SearchStocks(ctx, "samsung", 10)
+	assert.NoError(t, err)
+	assert.Len(t, matches, 1)
+	assert.Equal(t, "Samsung", matches[0].Name)
+}
+
+func TestSearchStocks(t *testing.T) {
+	for name, newRepo := range map[string]func(t *testing.T) StockRepository{
+		"memory": func(t *testing.T) StockRepository { return NewMemoryStockRepo() },
+		"sqlite": func(t *testing.T) StockRepository { return NewStockRepo(newSQLiteDatabase(t)) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newRepo(t)
+			for _, stock := range sampleStocks() {
+				assert.NoError(t, repo.CreateStock(ctx, &stock))
+			}
+
+			matches, err := repo.SearchStocks(ctx, "samsng", 10)
+			assert.NoError(t, err)
+			assert.Len(t, matches, 1)
+			assert.Equal(t, "Samsung", matches[0].Name)
+			assert.NotZero(t, matches[0].ID)
+
+			matches, err = repo.SearchStocks(ctx, "Micro", 10)
+			assert.NoError(t, err)
+			assert.Len(t, matches, 1)
+			assert.Equal(t, "Microsoft", matches[0].Name)
+
+			// Wildcards are searched as text
+			for _, q := range []string{"%", "a_p%"} {
+				matches, err = repo.SearchStocks(ctx, q, 10)
+				assert.NoError(t, err)
+				assert.Empty(t, matches, q)
+			}
+		})
+	}
+}
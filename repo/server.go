@@ -2,12 +2,17 @@ package repo
 
 // server is a struct that contains all the repositories
 type server struct {
-	StockRepo *StockRepo
+	StockRepo  StockRepository
+	ApiKeyRepo ApiKeyRepository
+	FxRateRepo FxRateRepository
+	AuditRepo  AuditRepository
 }
 
-
-func NewServer(stockRepo *StockRepo) *server {
+func NewServer(stockRepo StockRepository, apiKeyRepo ApiKeyRepository, fxRateRepo FxRateRepository, auditRepo AuditRepository) *server {
 	return &server{
-		StockRepo: stockRepo,
+		StockRepo:  stockRepo,
+		ApiKeyRepo: apiKeyRepo,
+		FxRateRepo: fxRateRepo,
+		AuditRepo:  auditRepo,
 	}
 }
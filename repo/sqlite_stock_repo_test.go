@@ -0,0 +1,321 @@
+package repo
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"stock-api/util"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm/logger"
+)
+
+func newSQLiteDatabase(t *testing.T) *Database {
+	db := &Database{
+		Driver:   DriverSQLite,
+		DbPath:   filepath.Join(t.TempDir(), "stock.db"),
+		LogLevel: logger.Silent,
+	}
+	db.Connect()
+	t.Cleanup(db.Close)
+
+	migrator, err := NewMigrator(db.DB())
+	assert.NoError(t, err)
+	_, err = migrator.Up(context.Background())
+	assert.NoError(t, err)
+
+	// Start from empty tables rather than the sample data
+	assert.NoError(t, db.DB().Exec("DELETE FROM stock_prices").Error)
+	assert.NoError(t, db.DB().Exec("DELETE FROM stocks").Error)
+	assert.NoError(t, db.DB().Exec("DELETE FROM sqlite_sequence").Error)
+	return db
+}
+
+func TestSQLiteStockRepo_CRUD(t *testing.T) {
+	ctx := context.Background()
+	repo := &StockRepo{Db: newSQLiteDatabase(t)}
+
+	// Create a stock and read it back
+	apple := &Stock{Name: "Apple", CurrentPrice: util.MustParseDecimal("100.0"), LastUpdate: time.Now()}
+	assert.NoError(t, repo.CreateStock(ctx, apple))
+	assert.Equal(t, util.PublicID(1), apple.ID)
+
+	stock, err := repo.GetStockByID(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "Apple", stock.Name)
+
+	// Update the price
+	stock.CurrentPrice = util.MustParseDecimal("110.0")
+	assert.NoError(t, repo.UpdateStock(ctx, stock))
+	stock, err = repo.GetStockByID(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, util.MustParseDecimal("110.0"), stock.CurrentPrice)
+
+	// Prices round trip exactly
+	stock.CurrentPrice = util.MustParseDecimal("100.49999")
+	assert.NoError(t, repo.UpdateStock(ctx, stock))
+	stock, err = repo.GetStockByID(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "100.49999", stock.CurrentPrice.String())
+
+	// Paginate
+	assert.NoError(t, repo.CreateStock(ctx, &Stock{Name: "Google", CurrentPrice: util.MustParseDecimal("150.0")}))
+	stocks, err := repo.FindStocks(ctx, StockQuery{Offset: 1, Limit: 1})
+	assert.NoError(t, err)
+	assert.Len(t, stocks, 1)
+	assert.Equal(t, "Google", stocks[0].Name)
+
+	count, err := repo.CountStocks(ctx, StockQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	stocks, err = repo.FindStocks(ctx, StockQuery{AfterID: 1, Limit: 10})
+	assert.NoError(t, err)
+	assert.Len(t, stocks, 1)
+	assert.Equal(t, "Google", stocks[0].Name)
+
+	// Delete
+	assert.NoError(t, repo.DeleteStock(ctx, 1))
+	_, err = repo.GetStockByID(ctx, 1)
+	assert.ErrorIs(t, err, ErrStockNotFound)
+}
+
+func TestSQLiteStockRepo_PricesAndCandles(t *testing.T) {
+	ctx := context.Background()
+	repo := &StockRepo{Db: newSQLiteDatabase(t)}
+
+	start := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	stock := &Stock{Name: "Apple", CurrentPrice: util.MustParseDecimal("100.0"), LastUpdate: start}
+	assert.NoError(t, repo.CreateStock(ctx, stock))
+
+	for i, price := range []string{"120", "90", "105"} {
+		stock.CurrentPrice = util.MustParseDecimal(price)
+		stock.LastUpdate = start.Add(time.Duration(i+1) * 20 * time.Minute)
+		assert.NoError(t, repo.UpdateStock(ctx, stock))
+	}
+
+	// Every price change is recorded in order
+	prices, err := repo.GetStockPrices(ctx, 1, time.Time{}, time.Time{})
+	assert.NoError(t, err)
+	assert.Len(t, prices, 4)
+	assert.Equal(t, util.PublicID(1), prices[0].StockID)
+	assert.Equal(t, util.MustParseDecimal("105.0"), prices[3].Price)
+
+	prices, err = repo.GetStockPrices(ctx, 1, start.Add(30*time.Minute), time.Time{})
+	assert.NoError(t, err)
+	assert.Len(t, prices, 2)
+
+	// Candles are aggregated outside of the database
+	candles, err := repo.GetStockCandles(ctx, 1, CandleInterval1h, time.Time{}, time.Time{})
+	assert.NoError(t, err)
+	assert.Equal(t, []Candle{
+		{Start: start, Open: util.MustParseDecimal("100.0"), High: util.MustParseDecimal("120.0"), Low: util.MustParseDecimal("90.0"), Close: util.MustParseDecimal("90.0"), Count: 3},
+		{Start: start.Add(time.Hour), Open: util.MustParseDecimal("105.0"), High: util.MustParseDecimal("105.0"), Low: util.MustParseDecimal("105.0"), Close: util.MustParseDecimal("105.0"), Count: 1},
+	}, candles)
+
+	_, err = repo.GetStockCandles(ctx, 1, CandleInterval("2h"), time.Time{}, time.Time{})
+	assert.ErrorIs(t, err, ErrInvalidCandleInterval)
+}
+
+func TestStockSymbols(t *testing.T) {
+	for name, newRepo := range map[string]func(t *testing.T) StockRepository{
+		"memory": func(t *testing.T) StockRepository { return NewMemoryStockRepo() },
+		"sqlite": func(t *testing.T) StockRepository { return NewStockRepo(newSQLiteDatabase(t)) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newRepo(t)
+
+			apple := &Stock{Name: "Apple", Symbol: "AAPL", Exchange: "NASDAQ", Currency: "USD", ISIN: "US0378331005"}
+			assert.NoError(t, repo.CreateStock(ctx, apple))
+
+			// Look a stock up by symbol
+			stock, err := repo.GetStockBySymbol(ctx, "NASDAQ", "AAPL")
+			assert.NoError(t, err)
+			assert.Equal(t, apple.ID, stock.ID)
+			assert.Equal(t, "US0378331005", stock.ISIN)
+
+			_, err = repo.GetStockBySymbol(ctx, "NYSE", "AAPL")
+			assert.ErrorIs(t, err, ErrStockNotFound)
+
+			// A symbol is unique on its exchange only
+			assert.ErrorIs(t, repo.CreateStock(ctx, &Stock{Name: "Apple again", Symbol: "AAPL", Exchange: "NASDAQ"}), ErrConflict)
+			assert.NoError(t, repo.CreateStock(ctx, &Stock{Name: "Apple", Symbol: "AAPL", Exchange: "XETRA"}))
+
+			microsoft := &Stock{Name: "Microsoft", Symbol: "MSFT", Exchange: "NASDAQ"}
+			assert.NoError(t, repo.CreateStock(ctx, microsoft))
+			microsoft.Symbol = "AAPL"
+			assert.ErrorIs(t, repo.UpdateStock(ctx, microsoft), ErrConflict)
+
+			// Stocks without a symbol never collide
+			assert.NoError(t, repo.CreateStock(ctx, &Stock{Name: "Unlisted"}))
+			assert.NoError(t, repo.CreateStock(ctx, &Stock{Name: "Unlisted"}))
+		})
+	}
+}
+
+func TestSoftDeleteStocks(t *testing.T) {
+	for name, newRepo := range map[string]func(t *testing.T) StockRepository{
+		"memory": func(t *testing.T) StockRepository { return NewMemoryStockRepo() },
+		"sqlite": func(t *testing.T) StockRepository { return NewStockRepo(newSQLiteDatabase(t)) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newRepo(t)
+
+			apple := &Stock{Name: "Apple", Symbol: "AAPL", Exchange: "NASDAQ", CurrentPrice: util.MustParseDecimal("100.5")}
+			assert.NoError(t, repo.CreateStock(ctx, apple))
+			microsoft := &Stock{Name: "Microsoft", Symbol: "MSFT", Exchange: "NASDAQ"}
+			assert.NoError(t, repo.CreateStock(ctx, microsoft))
+
+			// A deleted stock is hidden unless the query includes deleted stocks
+			assert.NoError(t, repo.DeleteStock(ctx, uint(apple.ID)))
+			_, err := repo.GetStockByID(ctx, uint(apple.ID))
+			assert.ErrorIs(t, err, ErrStockNotFound)
+
+			stocks, err := repo.FindStocks(ctx, StockQuery{})
+			assert.NoError(t, err)
+			assert.Len(t, stocks, 1)
+
+			stocks, err = repo.FindStocks(ctx, StockQuery{IncludeDeleted: true})
+			assert.NoError(t, err)
+			assert.Len(t, stocks, 2)
+			assert.True(t, stocks[0].DeletedAt.Valid)
+
+			count, err := repo.CountStocks(ctx, StockQuery{IncludeDeleted: true})
+			assert.NoError(t, err)
+			assert.Equal(t, int64(2), count)
+
+			// Restore it along with its price history
+			_, err = repo.RestoreStock(ctx, uint(microsoft.ID))
+			assert.ErrorIs(t, err, ErrStockNotFound)
+
+			restored, err := repo.RestoreStock(ctx, uint(apple.ID))
+			assert.NoError(t, err)
+			assert.False(t, restored.DeletedAt.Valid)
+			assert.Equal(t, "100.5", restored.CurrentPrice.String())
+
+			prices, err := repo.GetStockPrices(ctx, uint(apple.ID), time.Time{}, time.Time{})
+			assert.NoError(t, err)
+			assert.Len(t, prices, 1)
+
+			// A deleted stock frees its symbol, and can't be restored while it is taken
+			assert.NoError(t, repo.DeleteStock(ctx, uint(apple.ID)))
+			assert.NoError(t, repo.CreateStock(ctx, &Stock{Name: "Apple relisted", Symbol: "AAPL", Exchange: "NASDAQ"}))
+			_, err = repo.RestoreStock(ctx, uint(apple.ID))
+			assert.ErrorIs(t, err, ErrConflict)
+
+			// Purge the stocks deleted before a given time only
+			purged, err := repo.PurgeStocks(ctx, time.Now().Add(-time.Hour))
+			assert.NoError(t, err)
+			assert.Zero(t, purged)
+
+			purged, err = repo.PurgeStocks(ctx, time.Now().Add(time.Second))
+			assert.NoError(t, err)
+			assert.Equal(t, int64(1), purged)
+
+			_, err = repo.RestoreStock(ctx, uint(apple.ID))
+			assert.ErrorIs(t, err, ErrStockNotFound)
+
+			prices, err = repo.GetStockPrices(ctx, uint(apple.ID), time.Time{}, time.Time{})
+			assert.NoError(t, err)
+			assert.Empty(t, prices)
+
+			count, err = repo.CountStocks(ctx, StockQuery{IncludeDeleted: true})
+			assert.NoError(t, err)
+			assert.Equal(t, int64(2), count)
+		})
+	}
+}
+
+func TestStockVersions(t *testing.T) {
+	for name, newRepo := range map[string]func(t *testing.T) StockRepository{
+		"memory": func(t *testing.T) StockRepository { return NewMemoryStockRepo() },
+		"sqlite": func(t *testing.T) StockRepository { return NewStockRepo(newSQLiteDatabase(t)) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newRepo(t)
+
+			// A new stock starts at version 1, whatever the client sent
+			apple := &Stock{Name: "Apple", Symbol: "AAPL", Exchange: "NASDAQ", Version: 7}
+			assert.NoError(t, repo.CreateStock(ctx, apple))
+			assert.Equal(t, uint(1), apple.Version)
+
+			// Two editors read the same version, the first update wins
+			first, err := repo.GetStockByID(ctx, uint(apple.ID))
+			assert.NoError(t, err)
+			second, err := repo.GetStockByID(ctx, uint(apple.ID))
+			assert.NoError(t, err)
+
+			first.CurrentPrice = util.MustParseDecimal("110")
+			assert.NoError(t, repo.UpdateStock(ctx, first))
+			assert.Equal(t, uint(2), first.Version)
+
+			second.CurrentPrice = util.MustParseDecimal("90")
+			assert.ErrorIs(t, repo.UpdateStock(ctx, second), ErrVersionConflict)
+			assert.Equal(t, uint(1), second.Version)
+
+			stock, err := repo.GetStockByID(ctx, uint(apple.ID))
+			assert.NoError(t, err)
+			assert.Equal(t, "110", stock.CurrentPrice.String())
+			assert.Equal(t, uint(2), stock.Version)
+
+			prices, err := repo.GetStockPrices(ctx, uint(apple.ID), time.Time{}, time.Time{})
+			assert.NoError(t, err)
+			assert.Len(t, prices, 2)
+		})
+	}
+}
+
+func TestSQLiteStockRepo_Unavailable(t *testing.T) {
+	repo := &StockRepo{Db: newSQLiteDatabase(t)}
+	assert.NoError(t, repo.CreateStock(context.Background(), &Stock{Name: "Apple", CurrentPrice: util.MustParseDecimal("100.0")}))
+
+	// A query that times out isn't reported as a missing stock
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now())
+	defer cancel()
+	_, err := repo.GetStockByID(ctx, 1)
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.NotErrorIs(t, err, ErrStockNotFound)
+
+	_, err = repo.GetStockByID(context.Background(), 2)
+	assert.ErrorIs(t, err, ErrStockNotFound)
+}
+
+func TestSQLiteStockRepo_ExactPrices(t *testing.T) {
+	ctx := context.Background()
+	db := newSQLiteDatabase(t)
+	repo := &StockRepo{Db: db}
+
+	// 0.1+0.2 is 0.30000000000000004 as binary floats, and a REAL column
+	// keeps only 15 significant digits
+	stock := &Stock{Name: "Apple", CurrentPrice: util.MustParseDecimal("0.3")}
+	assert.NoError(t, repo.CreateStock(ctx, stock))
+	stock.CurrentPrice = util.MustParseDecimal("12345678901.23456789")
+	assert.NoError(t, repo.UpdateStock(ctx, stock))
+
+	stored, err := repo.GetStockByID(ctx, uint(stock.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, "12345678901.23456789", stored.CurrentPrice.String())
+
+	prices, err := repo.GetStockPrices(ctx, uint(stock.ID), time.Time{}, time.Time{})
+	assert.NoError(t, err)
+	assert.Len(t, prices, 2)
+	assert.Equal(t, "0.3", prices[0].Price.String())
+	assert.Equal(t, "12345678901.23456789", prices[1].Price.String())
+
+	var storedAs string
+	assert.NoError(t, db.DB().Raw("SELECT typeof(current_price) FROM stocks WHERE id = ?", uint(stock.ID)).Scan(&storedAs).Error)
+	assert.Equal(t, "text", storedAs)
+
+	// Prices are still filtered as numbers
+	assert.NoError(t, repo.CreateStock(ctx, &Stock{Name: "Google", CurrentPrice: util.MustParseDecimal("9.5")}))
+	minPrice := util.MustParseDecimal("10")
+	stocks, err := repo.FindStocks(ctx, StockQuery{MinPrice: &minPrice})
+	assert.NoError(t, err)
+	assert.Len(t, stocks, 1)
+	assert.Equal(t, "Apple", stocks[0].Name)
+}
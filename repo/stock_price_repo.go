@@ -0,0 +1,67 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"stock-api/util"
+
+	"gorm.io/gorm"
+)
+
+// Sources of a recorded stock price.
+const (
+	PriceSourceCreate = "create"
+	PriceSourceUpdate = "update"
+)
+
+// StockPrice represents a single recorded price of a stock.
+type StockPrice struct {
+	ID         uint          `gorm:"primarykey" json:"-"`
+	StockID    util.PublicID `gorm:"index:idx_stock_prices_stock_recorded,priority:1;not null" json:"stockId" swaggertype:"string"`
+	Price      util.Decimal  `json:"price" swaggertype:"number"`
+	RecordedAt time.Time     `gorm:"index:idx_stock_prices_stock_recorded,priority:2;not null" json:"recordedAt"`
+	Source     string        `json:"source"`
+}
+
+// newStockPrice builds the price record of a stock, stamped in UTC with the
+// stock's last update time or the current time when it has none.
+func newStockPrice(stock *Stock, source string) *StockPrice {
+	recordedAt := stock.LastUpdate
+	if recordedAt.IsZero() {
+		recordedAt = time.Now()
+	}
+
+	return &StockPrice{
+		StockID:    stock.ID,
+		Price:      stock.CurrentPrice,
+		RecordedAt: recordedAt.UTC(),
+		Source:     source,
+	}
+}
+
+// GetStockPrices retrieves the recorded prices of a stock ordered by time, then by ID.
+// A zero from or to leaves that side of the range open.
+func (repo *StockRepo) GetStockPrices(ctx context.Context, stockID uint, from, to time.Time) ([]StockPrice, error) {
+	db, cancel := repo.Db.WithContext(ctx)
+	defer cancel()
+
+	var prices []StockPrice
+	query := filterPriceRange(db.Where("stock_id = ?", stockID), from, to)
+
+	result := query.Order("recorded_at ASC, id ASC").Find(&prices)
+	if result.Error != nil {
+		return nil, translateStockError(result.Error)
+	}
+	return prices, nil
+}
+
+func filterPriceRange(query *gorm.DB, from, to time.Time) *gorm.DB {
+	if !from.IsZero() {
+		query = query.Where("recorded_at >= ?", from)
+	}
+	if !to.IsZero() {
+		query = query.Where("recorded_at <= ?", to)
+	}
+	return query
+}
@@ -0,0 +1,184 @@
+package repo
+
+import (
+	"cmp"
+	"sort"
+	"strings"
+	"time"
+
+	"stock-api/util"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// stockSortColumns whitelists the sortable stock fields by their JSON name.
+var stockSortColumns = map[string]string{
+	"id":           "id",
+	"name":         "name",
+	"currentPrice": "current_price",
+	"lastUpdate":   "last_update",
+}
+
+// StockSort orders stocks by a field, ascending unless Desc is set.
+type StockSort struct {
+	Field string
+	Desc  bool
+}
+
+// ParseStockSort parses a comma separated list of sortable fields, each
+// prefixed with "-" to sort descending, e.g. "name,-currentPrice".
+func ParseStockSort(value string) ([]StockSort, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	sorts := []StockSort{}
+	for _, field := range strings.Split(value, ",") {
+		desc := strings.HasPrefix(field, "-")
+		field = strings.TrimPrefix(field, "-")
+		if _, ok := stockSortColumns[field]; !ok {
+			return nil, ErrInvalidSort
+		}
+		sorts = append(sorts, StockSort{Field: field, Desc: desc})
+	}
+	return sorts, nil
+}
+
+// StockQuery filters, sorts and paginates a list of stocks. Zero fields are ignored.
+type StockQuery struct {
+	// Name keeps the stocks whose name contains it, ignoring case
+	Name         string
+	MinPrice     *util.Decimal
+	MaxPrice     *util.Decimal
+	UpdatedSince time.Time
+	// IncludeDeleted keeps the deleted stocks that aren't purged yet
+	IncludeDeleted bool
+	// Sort orders the stocks, ties and an empty sort are ordered by ID
+	Sort []StockSort
+	// AfterID keeps the stocks with a greater ID, for keyset pagination
+	AfterID uint
+	Offset  int
+	Limit   int
+}
+
+// Matches reports whether a stock passes the filters of the query.
+func (q StockQuery) Matches(stock Stock) bool {
+	if stock.DeletedAt.Valid && !q.IncludeDeleted {
+		return false
+	}
+	if q.Name != "" && !strings.Contains(strings.ToLower(stock.Name), strings.ToLower(q.Name)) {
+		return false
+	}
+	if q.MinPrice != nil && stock.CurrentPrice.Cmp(*q.MinPrice) < 0 {
+		return false
+	}
+	if q.MaxPrice != nil && stock.CurrentPrice.Cmp(*q.MaxPrice) > 0 {
+		return false
+	}
+	if !q.UpdatedSince.IsZero() && stock.LastUpdate.Before(q.UpdatedSince) {
+		return false
+	}
+	return uint(stock.ID) > q.AfterID
+}
+
+// filter applies the filters of the query to a stock query.
+func (q StockQuery) filter(db *gorm.DB) *gorm.DB {
+	if q.IncludeDeleted {
+		db = db.Unscoped()
+	}
+	if q.Name != "" {
+		db = db.Where(`LOWER(name) LIKE ? ESCAPE '\'`, "%"+escapeLike(strings.ToLower(q.Name))+"%")
+	}
+	if q.MinPrice != nil {
+		db = db.Where(priceColumn(db)+" >= ?", *q.MinPrice)
+	}
+	if q.MaxPrice != nil {
+		db = db.Where(priceColumn(db)+" <= ?", *q.MaxPrice)
+	}
+	if !q.UpdatedSince.IsZero() {
+		db = db.Where("last_update >= ?", q.UpdatedSince)
+	}
+	if q.AfterID > 0 {
+		db = db.Where("id > ?", q.AfterID)
+	}
+	return db
+}
+
+// order applies the sort and pagination of the query to a stock query.
+func (q StockQuery) order(db *gorm.DB) *gorm.DB {
+	for _, s := range q.Sort {
+		column := clause.Column{Name: stockSortColumns[s.Field]}
+		if s.Field == "currentPrice" {
+			column = clause.Column{Name: priceColumn(db), Raw: true}
+		}
+		db = db.Order(clause.OrderByColumn{Column: column, Desc: s.Desc})
+	}
+	db = db.Order("id")
+
+	if q.Offset > 0 {
+		db = db.Offset(q.Offset)
+	}
+	if q.Limit > 0 {
+		db = db.Limit(q.Limit)
+	}
+	return db
+}
+
+// apply filters, sorts and paginates stocks in memory like the query would in SQL.
+func (q StockQuery) apply(stocks []Stock) []Stock {
+	matching := []Stock{}
+	for _, stock := range stocks {
+		if q.Matches(stock) {
+			matching = append(matching, stock)
+		}
+	}
+
+	sort.SliceStable(matching, func(i, j int) bool {
+		for _, s := range q.Sort {
+			if order := compareStocks(matching[i], matching[j], s.Field); order != 0 {
+				return (order < 0) != s.Desc
+			}
+		}
+		return matching[i].ID < matching[j].ID
+	})
+
+	if q.Offset > 0 {
+		if q.Offset > len(matching) {
+			q.Offset = len(matching)
+		}
+		matching = matching[q.Offset:]
+	}
+	if q.Limit > 0 && q.Limit < len(matching) {
+		matching = matching[:q.Limit]
+	}
+	return matching
+}
+
+// compareStocks compares a sortable field of two stocks.
+func compareStocks(a, b Stock, field string) int {
+	switch field {
+	case "name":
+		return strings.Compare(a.Name, b.Name)
+	case "currentPrice":
+		return a.CurrentPrice.Cmp(b.CurrentPrice)
+	case "lastUpdate":
+		return a.LastUpdate.Compare(b.LastUpdate)
+	default:
+		return cmp.Compare(a.ID, b.ID)
+	}
+}
+
+// priceColumn returns the SQL expression comparing the prices of stocks as numbers.
+// SQLite stores them as exact text, which it compares as floats.
+func priceColumn(db *gorm.DB) string {
+	if db.Dialector.Name() == DriverSQLite {
+		return "CAST(current_price AS REAL)"
+	}
+	return "current_price"
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern.
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
@@ -0,0 +1,87 @@
+package repo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"stock-api/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseStockSort(t *testing.T) {
+	sorts, err := ParseStockSort("name,-currentPrice")
+	assert.NoError(t, err)
+	assert.Equal(t, []StockSort{{Field: "name"}, {Field: "currentPrice", Desc: true}}, sorts)
+
+	sorts, err = ParseStockSort("")
+	assert.NoError(t, err)
+	assert.Empty(t, sorts)
+
+	for _, value := range []string{"secret", "name,", "-", "current_price", "name;DROP TABLE stocks"} {
+		_, err := ParseStockSort(value)
+		assert.ErrorIs(t, err, ErrInvalidSort, value)
+	}
+}
+
+func TestFindStocks(t *testing.T) {
+	start := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	price := func(p string) *util.Decimal { d := util.MustParseDecimal(p); return &d }
+
+	for name, newRepo := range map[string]func(t *testing.T) StockRepository{
+		"memory": func(t *testing.T) StockRepository { return NewMemoryStockRepo() },
+		"sqlite": func(t *testing.T) StockRepository { return NewStockRepo(newSQLiteDatabase(t)) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newRepo(t)
+
+			// Create a few stocks updated an hour apart
+			for i, stock := range []Stock{
+				{Name: "Apple", CurrentPrice: util.MustParseDecimal("100.0")},
+				{Name: "Pineapple Farms", CurrentPrice: util.MustParseDecimal("20.0")},
+				{Name: "Microsoft", CurrentPrice: util.MustParseDecimal("100.0")},
+				{Name: "100%_Real", CurrentPrice: util.MustParseDecimal("5.0")},
+			} {
+				stock.LastUpdate = start.Add(time.Duration(i) * time.Hour)
+				assert.NoError(t, repo.CreateStock(ctx, &stock))
+			}
+
+			for _, tc := range []struct {
+				desc     string
+				query    StockQuery
+				expected []string
+			}{
+				{"all by ID", StockQuery{}, []string{"Apple", "Pineapple Farms", "Microsoft", "100%_Real"}},
+				{"name contains, ignoring case", StockQuery{Name: "APPLE"}, []string{"Apple", "Pineapple Farms"}},
+				{"name wildcards are literal", StockQuery{Name: "%_"}, []string{"100%_Real"}},
+				{"price range", StockQuery{MinPrice: price("10"), MaxPrice: price("100")}, []string{"Apple", "Pineapple Farms", "Microsoft"}},
+				{"updated since", StockQuery{UpdatedSince: start.Add(2 * time.Hour)}, []string{"Microsoft", "100%_Real"}},
+				{"sort with ID ties", StockQuery{Sort: []StockSort{{Field: "currentPrice", Desc: true}}}, []string{"Apple", "Microsoft", "Pineapple Farms", "100%_Real"}},
+				{"sort by several fields", StockQuery{Sort: []StockSort{{Field: "currentPrice"}, {Field: "name", Desc: true}}}, []string{"100%_Real", "Pineapple Farms", "Microsoft", "Apple"}},
+				{"page", StockQuery{Sort: []StockSort{{Field: "name"}}, Offset: 1, Limit: 2}, []string{"Apple", "Microsoft"}},
+				{"after ID", StockQuery{AfterID: 2, Limit: 1}, []string{"Microsoft"}},
+			} {
+				stocks, err := repo.FindStocks(ctx, tc.query)
+				assert.NoError(t, err)
+
+				names := []string{}
+				for _, stock := range stocks {
+					names = append(names, stock.Name)
+				}
+				assert.Equal(t, tc.expected, names, tc.desc)
+
+				// The count ignores the pagination
+				unpaged := tc.query
+				unpaged.Offset, unpaged.Limit = 0, 0
+				all, err := repo.FindStocks(ctx, unpaged)
+				assert.NoError(t, err)
+
+				count, err := repo.CountStocks(ctx, tc.query)
+				assert.NoError(t, err)
+				assert.Equal(t, int64(len(all)), count, tc.desc)
+			}
+		})
+	}
+}
@@ -1,15 +1,50 @@
 package repo
 
 import (
+	"context"
+	"errors"
+	"strings"
 	"time"
+
+	"stock-api/util"
+
+	"gorm.io/gorm"
 )
 
 // Stock represents the stock entity.
+// Its ID is exposed to clients as an opaque string.
+// A symbol is unique on its exchange among the stocks that aren't deleted, which the
+// partial index idx_stocks_exchange_symbol of migration 0007 enforces: the schema is
+// built by the migrations only, the gorm tags don't declare it.
+// Deleted stocks are kept, hidden from queries, until they are purged.
+// Version counts the updates of a stock, an update must start from the current version.
 type Stock struct {
-	ID           uint      `gorm:"primarykey"`
-	Name         string    `json:"name"`
-	CurrentPrice float64   `json:"currentPrice"`
-	LastUpdate   time.Time `json:"lastUpdate"`
+	ID           util.PublicID  `gorm:"primarykey" swaggertype:"string"`
+	Name         string         `json:"name"`
+	Symbol       string         `json:"symbol" example:"AAPL"`
+	Exchange     string         `json:"exchange" example:"NASDAQ"`
+	Currency     string         `json:"currency" example:"USD"`
+	ISIN         string         `gorm:"column:isin" json:"isin" example:"US0378331005"`
+	CurrentPrice util.Decimal   `json:"currentPrice" swaggertype:"number" example:"100.5"`
+	LastUpdate   time.Time      `json:"lastUpdate"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"deletedAt" swaggertype:"string" format:"date-time"`
+	Version      uint           `gorm:"not null;default:1" json:"version" example:"1"`
+	// Fx is only set on stocks converted into another currency
+	Fx *FxConversion `gorm:"-" json:"fx,omitempty"`
+}
+
+// Normalize trims the identifiers of the stock and upper cases them,
+// so symbols are compared regardless of how clients type them.
+func (s *Stock) Normalize() {
+	s.Symbol = NormalizeIdentifier(s.Symbol)
+	s.Exchange = NormalizeIdentifier(s.Exchange)
+	s.Currency = NormalizeIdentifier(s.Currency)
+	s.ISIN = NormalizeIdentifier(s.ISIN)
+}
+
+// NormalizeIdentifier trims and upper cases a symbol, exchange, currency or ISIN.
+func NormalizeIdentifier(value string) string {
+	return strings.ToUpper(strings.TrimSpace(value))
 }
 
 // StockRepository represents the repository containing GORM instance.
@@ -18,12 +53,19 @@ type StockRepo struct {
 }
 
 type StockRepository interface {
-	CreateStock(stock *Stock) error
-	GetStocks() ([]Stock, error)
-	GetStockByID(id uint) (*Stock, error)
-	UpdateStock(stock *Stock) error
-	DeleteStock(id uint) error
-	GetPaginatedStocks(page, pageSize int) ([]Stock, error)
+	CreateStock(ctx context.Context, stock *Stock) error
+	GetStocks(ctx context.Context) ([]Stock, error)
+	GetStockByID(ctx context.Context, id uint) (*Stock, error)
+	GetStockBySymbol(ctx context.Context, exchange, symbol string) (*Stock, error)
+	UpdateStock(ctx context.Context, stock *Stock) error
+	DeleteStock(ctx context.Context, id uint) error
+	RestoreStock(ctx context.Context, id uint) (*Stock, error)
+	PurgeStocks(ctx context.Context, deletedBefore time.Time) (int64, error)
+	FindStocks(ctx context.Context, query StockQuery) ([]Stock, error)
+	CountStocks(ctx context.Context, query StockQuery) (int64, error)
+	SearchStocks(ctx context.Context, q string, limit int) ([]StockMatch, error)
+	GetStockPrices(ctx context.Context, stockID uint, from, to time.Time) ([]StockPrice, error)
+	GetStockCandles(ctx context.Context, stockID uint, interval CandleInterval, from, to time.Time) ([]Candle, error)
 }
 
 // NewStockRepository initializes a new StockRepository with a GORM instance.
@@ -31,61 +73,220 @@ func NewStockRepo(db *Database) *StockRepo {
 	return &StockRepo{db}
 }
 
-// CreateStock inserts a new stock into the database.
-func (s *StockRepo) CreateStock(stock *Stock) error {
-	result := s.Db.db.Create(stock)
-	if result.Error != nil {
-		return result.Error
-	}
-	return nil
+// CreateStock inserts a new stock into the database along with its initial price
+// and its audit event.
+func (s *StockRepo) CreateStock(ctx context.Context, stock *Stock) error {
+	db, cancel := s.Db.WithContext(ctx)
+	defer cancel()
+
+	stock.Version = 1
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(stock).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(newStockPrice(stock, PriceSourceCreate)).Error; err != nil {
+			return err
+		}
+		return recordStockAuditEvent(tx, AuditActionCreate, nil, stock)
+	})
+	return translateStockError(err)
 }
 
 // GetStocks retrieves a list of stocks from the database.
-func (s *StockRepo) GetStocks() ([]Stock, error) {
+func (s *StockRepo) GetStocks(ctx context.Context) ([]Stock, error) {
+	db, cancel := s.Db.WithContext(ctx)
+	defer cancel()
+
 	var stocks []Stock
-	result := s.Db.db.Find(&stocks)
+	result := db.Find(&stocks)
 	if result.Error != nil {
-		return nil, result.Error
+		return nil, translateStockError(result.Error)
 	}
 	return stocks, nil
 }
 
 // GetStockByID retrieves a single stock by ID from the database.
-func (repo *StockRepo) GetStockByID(id uint) (*Stock, error) {
+// It fails with ErrStockNotFound when no stock has the ID, and with
+// ErrUnavailable when the database can't be reached.
+func (repo *StockRepo) GetStockByID(ctx context.Context, id uint) (*Stock, error) {
+	db, cancel := repo.Db.WithContext(ctx)
+	defer cancel()
+
 	var stock Stock
-	result := repo.Db.db.First(&stock, id)
+	result := db.First(&stock, id)
 	if result.Error != nil {
-		return nil, result.Error
+		return nil, translateStockError(result.Error)
 	}
 	return &stock, nil
 }
 
-// UpdateStock updates the price of a single stock in the database.
-func (repo *StockRepo) UpdateStock(stock *Stock) error {
-	result := repo.Db.db.Save(stock)
-	if result.Error != nil {
-		return result.Error
+// UpdateStock replaces every field of a single stock in the database but its ID,
+// along with its audit event.
+// The stored stock must still be at the version of stock, which is then incremented,
+// otherwise it fails with ErrVersionConflict. It fails with ErrStockNotFound when the stock
+// is missing, and with ErrConflict when another stock has its symbol.
+// A new price record is written whenever the price changes.
+func (repo *StockRepo) UpdateStock(ctx context.Context, stock *Stock) error {
+	db, cancel := repo.Db.WithContext(ctx)
+	defer cancel()
+
+	expected := stock.Version
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var current Stock
+		if err := tx.First(&current, uint(stock.ID)).Error; err != nil {
+			return err
+		}
+		if current.Version != expected {
+			return ErrVersionConflict
+		}
+
+		// the version condition holds even if the row changed since it was read
+		stock.Version = expected + 1
+		result := tx.Model(stock).Where("version = ?", expected).Select("*").Omit("id").Updates(stock)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrVersionConflict
+		}
+
+		if !current.CurrentPrice.Equal(stock.CurrentPrice) {
+			if err := tx.Create(newStockPrice(stock, PriceSourceUpdate)).Error; err != nil {
+				return err
+			}
+		}
+		return recordStockAuditEvent(tx, AuditActionUpdate, &current, stock)
+	})
+	if err != nil {
+		stock.Version = expected
 	}
-	return nil
+	return translateStockError(err)
 }
 
-// DeleteStock deletes a single stock from the database.
-func (repo *StockRepo) DeleteStock(id uint) error {
-	result := repo.Db.db.Delete(&Stock{}, id)
-	if result.Error != nil {
-		return result.Error
+// GetStockBySymbol retrieves a single stock by its exchange and symbol.
+func (repo *StockRepo) GetStockBySymbol(ctx context.Context, exchange, symbol string) (*Stock, error) {
+	db, cancel := repo.Db.WithContext(ctx)
+	defer cancel()
+
+	var stock Stock
+	if err := db.Where("exchange = ? AND symbol = ?", exchange, symbol).First(&stock).Error; err != nil {
+		return nil, translateStockError(err)
 	}
-	return nil
+	return &stock, nil
 }
 
-// GetPaginatedStocks retrieves a paginated list of stocks from the database.
-func (repo *StockRepo) GetPaginatedStocks(page, pageSize int) ([]Stock, error) {
-	var stocks []Stock
-	offset := (page - 1) * pageSize
+// DeleteStock soft deletes a single stock from the database, along with its audit event.
+// It can be restored until it is purged. Deleting a missing stock is not an error.
+func (repo *StockRepo) DeleteStock(ctx context.Context, id uint) error {
+	db, cancel := repo.Db.WithContext(ctx)
+	defer cancel()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var stock Stock
+		if err := tx.First(&stock, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
 
-	result := repo.Db.db.Offset(offset).Limit(pageSize).Find(&stocks)
+		before := stock
+		if err := tx.Delete(&stock).Error; err != nil {
+			return err
+		}
+		return recordStockAuditEvent(tx, AuditActionDelete, &before, &stock)
+	})
+	return translateStockError(err)
+}
+
+// RestoreStock undoes the deletion of a single stock, along with its audit event.
+// It fails with ErrStockNotFound when no deleted stock has the ID, and with
+// ErrConflict when another stock took its symbol meanwhile.
+func (repo *StockRepo) RestoreStock(ctx context.Context, id uint) (*Stock, error) {
+	db, cancel := repo.Db.WithContext(ctx)
+	defer cancel()
+
+	var stock Stock
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("deleted_at IS NOT NULL").First(&stock, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&Stock{}).Where("id = ?", id).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+
+		before := stock
+		stock.DeletedAt = gorm.DeletedAt{}
+		return recordStockAuditEvent(tx, AuditActionRestore, &before, &stock)
+	})
+	if err != nil {
+		return nil, translateStockError(err)
+	}
+	return &stock, nil
+}
+
+// PurgeStocks permanently deletes the stocks deleted before deletedBefore,
+// along with their price history, records an audit event per purged stock,
+// and returns how many were purged.
+func (repo *StockRepo) PurgeStocks(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	db, cancel := repo.Db.WithContext(ctx)
+	defer cancel()
+
+	var purged int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var stocks []Stock
+		if err := tx.Unscoped().Where("deleted_at < ?", deletedBefore).Order("id").Find(&stocks).Error; err != nil {
+			return err
+		}
+		if len(stocks) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, len(stocks))
+		for i, stock := range stocks {
+			ids[i] = uint(stock.ID)
+		}
+		if err := tx.Where("stock_id IN ?", ids).Delete(&StockPrice{}).Error; err != nil {
+			return err
+		}
+		result := tx.Unscoped().Delete(&Stock{}, ids)
+		if result.Error != nil {
+			return result.Error
+		}
+		purged = result.RowsAffected
+
+		for i := range stocks {
+			if err := recordStockAuditEvent(tx, AuditActionPurge, &stocks[i], nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return purged, translateStockError(err)
+}
+
+// FindStocks retrieves the stocks matching the filters of the query, sorted and paginated.
+func (repo *StockRepo) FindStocks(ctx context.Context, query StockQuery) ([]Stock, error) {
+	db, cancel := repo.Db.WithContext(ctx)
+	defer cancel()
+
+	var stocks []Stock
+	result := query.order(query.filter(db)).Find(&stocks)
 	if result.Error != nil {
-		return nil, result.Error
+		return nil, translateStockError(result.Error)
 	}
 	return stocks, nil
 }
+
+// CountStocks returns the number of stocks matching the filters of the query,
+// ignoring its sort and pagination.
+func (repo *StockRepo) CountStocks(ctx context.Context, query StockQuery) (int64, error) {
+	db, cancel := repo.Db.WithContext(ctx)
+	defer cancel()
+
+	var count int64
+	if err := query.filter(db.Model(&Stock{})).Count(&count).Error; err != nil {
+		return 0, translateStockError(err)
+	}
+	return count, nil
+}
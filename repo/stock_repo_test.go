@@ -1,8 +1,11 @@
 package repo
 
 import (
+	"context"
 	"testing"
 
+	"stock-api/util"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -15,13 +18,13 @@ func TestStockRepo_CreateStock(t *testing.T) {
 	stock := &Stock{
 		ID:           1,
 		Name:         "Test Stock",
-		CurrentPrice: 100.0,
+		CurrentPrice: util.MustParseDecimal("100.0"),
 	}
 	// Set expectations for the CreateStock method
-	mockDB.On("CreateStock", mock.Anything).Return(nil)
+	mockDB.On("CreateStock", mock.Anything, mock.Anything).Return(nil)
 
 	// Call the method being tested
-	err := mockDB.CreateStock(stock)
+	err := mockDB.CreateStock(context.Background(), stock)
 
 	// Assert that there are no errors
 	assert.NoError(t, err)
@@ -38,20 +41,20 @@ func TestStockRepo_GetStocks(t *testing.T) {
 		{
 			ID:           1,
 			Name:         "Stock A",
-			CurrentPrice: 100.0,
+			CurrentPrice: util.MustParseDecimal("100.0"),
 		},
 		{
 			ID:           2,
 			Name:         "Stock B",
-			CurrentPrice: 150.0,
+			CurrentPrice: util.MustParseDecimal("150.0"),
 		},
 	}
 
 	// Set expectations for the GetStocks method
-	mockDB.On("GetStocks").Return(mockData, nil)
+	mockDB.On("GetStocks", mock.Anything).Return(mockData, nil)
 
 	// Call the method being tested
-	stocks, err := mockDB.GetStocks()
+	stocks, err := mockDB.GetStocks(context.Background())
 
 	// Assert that there are no errors
 	assert.NoError(t, err)
@@ -74,14 +77,14 @@ func TestStockRepo_GetStockByID(t *testing.T) {
 	mockData := &Stock{
 		ID:           1,
 		Name:         "Stock A",
-		CurrentPrice: 100.0,
+		CurrentPrice: util.MustParseDecimal("100.0"),
 	}
 
 	// Set expectations for the GetStockByID method
-	mockDB.On("GetStockByID", stockID).Return(mockData, nil)
+	mockDB.On("GetStockByID", mock.Anything, stockID).Return(mockData, nil)
 
 	// Call the method being tested
-	stock, err := mockDB.GetStockByID(stockID)
+	stock, err := mockDB.GetStockByID(context.Background(), stockID)
 
 	// Assert that there are no errors
 	assert.NoError(t, err)
@@ -101,14 +104,14 @@ func TestStockRepo_UpdateStock(t *testing.T) {
 	stockToUpdate := &Stock{
 		ID:           1,
 		Name:         "Stock A",
-		CurrentPrice: 100.0,
+		CurrentPrice: util.MustParseDecimal("100.0"),
 	}
 
 	// Set expectations for the UpdateStock method
-	mockDB.On("UpdateStock", stockToUpdate).Return(nil)
+	mockDB.On("UpdateStock", mock.Anything, stockToUpdate).Return(nil)
 
 	// Call the method being tested
-	err := mockDB.UpdateStock(stockToUpdate)
+	err := mockDB.UpdateStock(context.Background(), stockToUpdate)
 
 	// Assert that there are no errors
 	assert.NoError(t, err)
@@ -125,10 +128,10 @@ func TestStockRepo_DeleteStock(t *testing.T) {
 	stockID := uint(1)
 
 	// Set expectations for the DeleteStock method
-	mockDB.On("DeleteStock", stockID).Return(nil)
+	mockDB.On("DeleteStock", mock.Anything, stockID).Return(nil)
 
 	// Call the method being tested
-	err := mockDB.DeleteStock(stockID)
+	err := mockDB.DeleteStock(context.Background(), stockID)
 
 	// Assert that there are no errors
 	assert.NoError(t, err)
@@ -0,0 +1,50 @@
+package util
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+const apiKeyPrefix = "sk"
+
+// GenerateAPIKey creates a new "sk_<prefix>_<secret>" API key.
+// The prefix identifies the key while only the hash of the secret is stored.
+func GenerateAPIKey() (key, prefix, secret string, err error) {
+	prefixBytes := make([]byte, 6)
+	if _, err = rand.Read(prefixBytes); err != nil {
+		return "", "", "", err
+	}
+
+	secretBytes := make([]byte, 32)
+	if _, err = rand.Read(secretBytes); err != nil {
+		return "", "", "", err
+	}
+
+	prefix = hex.EncodeToString(prefixBytes)
+	secret = base64.RawURLEncoding.EncodeToString(secretBytes)
+	return strings.Join([]string{apiKeyPrefix, prefix, secret}, "_"), prefix, secret, nil
+}
+
+// ParseAPIKey splits an API key into its prefix and secret.
+func ParseAPIKey(key string) (prefix, secret string, err error) {
+	parts := strings.SplitN(key, "_", 3)
+	if len(parts) != 3 || parts[0] != apiKeyPrefix || parts[1] == "" || parts[2] == "" {
+		return "", "", ErrInvalidAPIKey
+	}
+	return parts[1], parts[2], nil
+}
+
+// HashAPIKeySecret returns the hex encoded SHA-256 of an API key secret.
+func HashAPIKeySecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyAPIKeySecret compares a secret against its stored hash in constant time.
+func VerifyAPIKeySecret(secret, hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(HashAPIKeySecret(secret)), []byte(hash)) == 1
+}
@@ -0,0 +1,32 @@
+package util
+
+import (
+	"encoding/base64"
+	"encoding/json"
+)
+
+// cursorPayload is the position a pagination cursor resumes after.
+// The ID is keyed like any public ID, so cursors can't be forged to walk raw IDs.
+type cursorPayload struct {
+	After PublicID `json:"after"`
+}
+
+// EncodeCursor returns the opaque cursor of the page following the row with ID afterID.
+func EncodeCursor(afterID uint) string {
+	payload, _ := json.Marshal(cursorPayload{After: PublicID(afterID)})
+	return base64.RawURLEncoding.EncodeToString(payload)
+}
+
+// DecodeCursor returns the row ID a cursor resumes after, or ErrInvalidCursor.
+func DecodeCursor(cursor string) (uint, error) {
+	payload, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+
+	var decoded cursorPayload
+	if err := json.Unmarshal(payload, &decoded); err != nil || decoded.After == 0 {
+		return 0, ErrInvalidCursor
+	}
+	return uint(decoded.After), nil
+}
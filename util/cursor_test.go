@@ -0,0 +1,22 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	for _, id := range []uint{1, 42, 1 << 40} {
+		id2, err := DecodeCursor(EncodeCursor(id))
+		assert.NoError(t, err)
+		assert.Equal(t, id, id2)
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	for _, cursor := range []string{"", "not base64!", "bm90IGpzb24", EncodeID(1), "eyJhZnRlciI6IjAwMDAwMDAwMDAxIn0"} {
+		_, err := DecodeCursor(cursor)
+		assert.ErrorIs(t, err, ErrInvalidCursor, cursor)
+	}
+}
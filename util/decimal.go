@@ -0,0 +1,231 @@
+package util
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DecimalScale is the number of decimal places a Decimal holds.
+const DecimalScale = 8
+
+var (
+	decimalOne    = big.NewInt(100_000_000) // 10^DecimalScale
+	decimalFormat = regexp.MustCompile(`^[+-]?(\d+\.?\d*|\.\d+)([eE][+-]?\d{1,2})?$`)
+)
+
+// Decimal is an exact fixed-point number with DecimalScale decimal places,
+// for money where binary floats would drift.
+// It ranges from -MaxDecimal to MaxDecimal, about 9.22e10: less than the 1e11
+// NUMERIC(19, 8) price columns of Postgres allow, so larger prices written there
+// by other clients fail to scan.
+// The zero value is 0.
+type Decimal struct {
+	units int64 // value * 10^DecimalScale
+}
+
+// MaxDecimal is the largest Decimal, 92233720368.54775807.
+var MaxDecimal = Decimal{units: math.MaxInt64}
+
+// ParseDecimal parses a decimal number such as "100.5", "-3" or "1.5e3".
+// It fails with ErrInvalidDecimal when s has more than DecimalScale decimal
+// places or doesn't fit.
+func ParseDecimal(s string) (Decimal, error) {
+	return parseDecimal(s, false)
+}
+
+// MustParseDecimal is ParseDecimal panicking on invalid numbers, for constants.
+func MustParseDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// NewDecimalFromInt returns the decimal of an integer.
+func NewDecimalFromInt(i int64) Decimal {
+	return Decimal{units: i * decimalOne.Int64()}
+}
+
+// parseDecimal parses s, rounding half away from zero to DecimalScale places when round is set.
+func parseDecimal(s string, round bool) (Decimal, error) {
+	units, err := parseUnits(s, decimalOne, round)
+	if err != nil {
+		return Decimal{}, err
+	}
+	if !units.IsInt64() || units.Int64() == math.MinInt64 {
+		return Decimal{}, fmt.Errorf("%w: %s is beyond ±%s", ErrInvalidDecimal, s, MaxDecimal)
+	}
+	return Decimal{units: units.Int64()}, nil
+}
+
+// parseUnits parses s into a count of 1/one units, rounding half away from zero
+// when round is set.
+func parseUnits(s string, one *big.Int, round bool) (*big.Int, error) {
+	if !decimalFormat.MatchString(s) {
+		return nil, ErrInvalidDecimal
+	}
+
+	value, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return nil, ErrInvalidDecimal
+	}
+	value.Mul(value, new(big.Rat).SetInt(one))
+
+	if value.IsInt() {
+		return new(big.Int).Set(value.Num()), nil
+	}
+	if round {
+		return roundQuo(value.Num(), value.Denom()), nil
+	}
+	return nil, ErrInvalidDecimal
+}
+
+// roundQuo divides a by b, rounding half away from zero.
+func roundQuo(a, b *big.Int) *big.Int {
+	quo, rem := new(big.Int).QuoRem(a, b, new(big.Int))
+	if new(big.Int).Mul(new(big.Int).Abs(rem), big.NewInt(2)).CmpAbs(b) >= 0 {
+		if a.Sign()*b.Sign() < 0 {
+			quo.Sub(quo, big.NewInt(1))
+		} else {
+			quo.Add(quo, big.NewInt(1))
+		}
+	}
+	return quo
+}
+
+// String returns the shortest exact representation of d, e.g. "100.5".
+func (d Decimal) String() string {
+	return formatUnits(big.NewInt(d.units), DecimalScale)
+}
+
+// formatUnits returns the shortest exact representation of units of 10^-scale.
+func formatUnits(units *big.Int, scale int) string {
+	sign := ""
+	if units.Sign() < 0 {
+		sign = "-"
+	}
+
+	digits := fmt.Sprintf("%0*s", scale+1, new(big.Int).Abs(units).String())
+	integer, fraction := digits[:len(digits)-scale], strings.TrimRight(digits[len(digits)-scale:], "0")
+	if fraction == "" {
+		return sign + integer
+	}
+	return sign + integer + "." + fraction
+}
+
+// Cmp compares d and other, returning -1, 0 or +1.
+func (d Decimal) Cmp(other Decimal) int {
+	switch {
+	case d.units < other.units:
+		return -1
+	case d.units > other.units:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Equal reports whether d and other are the same number.
+func (d Decimal) Equal(other Decimal) bool {
+	return d.units == other.units
+}
+
+// IsZero reports whether d is 0.
+func (d Decimal) IsZero() bool {
+	return d.units == 0
+}
+
+// IsNegative reports whether d is below 0.
+func (d Decimal) IsNegative() bool {
+	return d.units < 0
+}
+
+// Mul returns d * other rounded half away from zero to DecimalScale places.
+// It fails with ErrInvalidDecimal when the product doesn't fit.
+func (d Decimal) Mul(other Decimal) (Decimal, error) {
+	product := new(big.Int).Mul(big.NewInt(d.units), big.NewInt(other.units))
+	units := roundQuo(product, decimalOne)
+	if !units.IsInt64() {
+		return Decimal{}, ErrInvalidDecimal
+	}
+	return Decimal{units: units.Int64()}, nil
+}
+
+// MarshalJSON implements json.Marshaler, writing d as an exact JSON number.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, reading a JSON number or string.
+// A JSON null leaves d unchanged.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	s, err := jsonNumberText(data)
+	if err != nil {
+		return err
+	}
+
+	parsed, err := ParseDecimal(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// jsonNumberText returns the text of a JSON number or string.
+func jsonNumberText(data []byte) (string, error) {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var number json.Number
+		if err := json.Unmarshal(data, &number); err != nil {
+			return "", ErrInvalidDecimal
+		}
+		s = number.String()
+	}
+	return s, nil
+}
+
+// Value implements driver.Valuer, sending d as its exact string.
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// Scan implements sql.Scanner. Databases without a decimal type return floats,
+// which are rounded to DecimalScale places. It fails with ErrInvalidDecimal
+// when the value is beyond MaxDecimal.
+func (d *Decimal) Scan(value interface{}) error {
+	var s string
+	switch v := value.(type) {
+	case nil:
+		*d = Decimal{}
+		return nil
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	case int64:
+		s = strconv.FormatInt(v, 10)
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Errorf("%w: can't scan %T", ErrInvalidDecimal, value)
+	}
+
+	parsed, err := parseDecimal(s, true)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
@@ -0,0 +1,106 @@
+package util
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDecimal(t *testing.T) {
+	for input, expected := range map[string]string{
+		"100.50":      "100.5",
+		"100.49999":   "100.49999",
+		"-3":          "-3",
+		"+0.00000001": "0.00000001",
+		".5":          "0.5",
+		"1.5e3":       "1500",
+		"12e-8":       "0.00000012",
+		"0":           "0",
+		"-0.0":        "0",
+	} {
+		d, err := ParseDecimal(input)
+		assert.NoError(t, err, input)
+		assert.Equal(t, expected, d.String(), input)
+	}
+
+	for _, input := range []string{"", "abc", "1.2.3", "1/3", "0x10", "1e999", "0.000000001", "92233720368.54775808", "NaN", " 1"} {
+		_, err := ParseDecimal(input)
+		assert.ErrorIs(t, err, ErrInvalidDecimal, input)
+	}
+}
+
+func TestDecimalJSON(t *testing.T) {
+	var value struct {
+		Price Decimal `json:"price"`
+	}
+
+	// Numbers and strings are read exactly
+	assert.NoError(t, json.Unmarshal([]byte(`{"price":100.49999}`), &value))
+	assert.Equal(t, MustParseDecimal("100.49999"), value.Price)
+	assert.NoError(t, json.Unmarshal([]byte(`{"price":"0.1"}`), &value))
+	assert.Equal(t, MustParseDecimal("0.1"), value.Price)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"price":true}`), &value))
+	assert.Error(t, json.Unmarshal([]byte(`{"price":"cheap"}`), &value))
+
+	// Decimals are written as exact numbers
+	data, err := json.Marshal(value)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"price":0.1}`, string(data))
+}
+
+func TestDecimalSQL(t *testing.T) {
+	value, err := MustParseDecimal("100.5").Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "100.5", value)
+
+	for _, tc := range []struct {
+		input    interface{}
+		expected string
+	}{
+		{"100.50000000", "100.5"},
+		{[]byte("75.25"), "75.25"},
+		{int64(42), "42"},
+		// floats of databases without decimals are rounded
+		{0.1 + 0.2, "0.3"},
+		{100.49999999999999, "100.5"},
+	} {
+		var d Decimal
+		assert.NoError(t, d.Scan(tc.input), tc.input)
+		assert.Equal(t, tc.expected, d.String(), tc.input)
+	}
+
+	var d Decimal
+	assert.ErrorIs(t, d.Scan(true), ErrInvalidDecimal)
+
+	// NUMERIC(19, 8) holds values beyond the range of Decimal
+	assert.NoError(t, d.Scan("92233720368.54775807"))
+	assert.Equal(t, MaxDecimal, d)
+	for _, value := range []interface{}{"92233720368.54775808", "99999999999.99999999", []byte("-92233720368.54775808"), int64(92233720369)} {
+		err := d.Scan(value)
+		assert.ErrorIs(t, err, ErrInvalidDecimal, value)
+		assert.ErrorContains(t, err, "is beyond ±92233720368.54775807", value)
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	a, b := MustParseDecimal("0.1"), MustParseDecimal("0.2")
+	assert.Equal(t, -1, a.Cmp(b))
+	assert.Equal(t, 1, b.Cmp(a))
+	assert.True(t, a.Equal(MustParseDecimal("0.10")))
+	assert.True(t, MustParseDecimal("-1").IsNegative())
+	assert.True(t, Decimal{}.IsZero())
+
+	// Products are rounded half away from zero
+	product, err := MustParseDecimal("1300.5").Mul(MustParseDecimal("0.00074123"))
+	assert.NoError(t, err)
+	assert.Equal(t, "0.96396962", product.String())
+
+	product, err = MustParseDecimal("-0.00000001").Mul(MustParseDecimal("0.5"))
+	assert.NoError(t, err)
+	assert.Equal(t, "-0.00000001", product.String())
+
+	_, err = MustParseDecimal("90000000000").Mul(MustParseDecimal("2"))
+	assert.ErrorIs(t, err, ErrInvalidDecimal)
+}
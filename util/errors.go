@@ -5,6 +5,11 @@ import (
 )
 
 var (
-	ErrInvalidJWT = errors.New("invalid jwt")
-	ErrInvalidID  = errors.New("invalid id")
+	ErrInvalidJWT         = errors.New("invalid jwt")
+	ErrInvalidID          = errors.New("invalid id")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrMissingIdentity    = errors.New("missing identity")
+	ErrInvalidAPIKey      = errors.New("invalid api key")
+	ErrInvalidCursor      = errors.New("invalid cursor")
+	ErrInvalidDecimal     = errors.New("invalid decimal")
 )
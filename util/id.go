@@ -0,0 +1,149 @@
+package util
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	idAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	idLength   = 11 // base62 digits needed for 64 bits
+	idRounds   = 4
+	// idBits is the width of encodable IDs, the remaining bits must decode to zero
+	idBits       = 48
+	defaultIDKey = "encode-id-key"
+)
+
+// MaxID is the largest ID that can be encoded, 2^48 - 1.
+const MaxID = 1<<idBits - 1
+
+// IDCodec reversibly turns numeric IDs into opaque strings with a keyed
+// Feistel permutation, so the strings don't reveal the row count.
+type IDCodec struct {
+	key []byte
+}
+
+// NewIDCodec creates a codec keyed with key.
+func NewIDCodec(key string) *IDCodec {
+	return &IDCodec{key: []byte(key)}
+}
+
+// Encode returns the opaque string of an ID. It panics when id is above MaxID,
+// as Decode would reject its string: callers must check IDs they don't control.
+func (c *IDCodec) Encode(id uint) string {
+	if uint64(id) > MaxID {
+		panic(fmt.Sprintf("util: id %d doesn't fit in %d bits", id, idBits))
+	}
+	block := c.permute(uint64(id), false)
+
+	digits := make([]byte, idLength)
+	for i := idLength - 1; i >= 0; i-- {
+		digits[i] = idAlphabet[block%62]
+		block /= 62
+	}
+	return string(digits)
+}
+
+// Decode returns the ID of an opaque string, or ErrInvalidID when the string
+// wasn't produced by Encode with the same key.
+func (c *IDCodec) Decode(s string) (uint, error) {
+	if len(s) != idLength {
+		return 0, ErrInvalidID
+	}
+
+	var block uint64
+	for i := 0; i < len(s); i++ {
+		digit := strings.IndexByte(idAlphabet, s[i])
+		if digit < 0 {
+			return 0, ErrInvalidID
+		}
+
+		next := block*62 + uint64(digit)
+		if next/62 != block {
+			return 0, ErrInvalidID
+		}
+		block = next
+	}
+
+	id := c.permute(block, true)
+	if id>>idBits != 0 {
+		return 0, ErrInvalidID
+	}
+	return uint(id), nil
+}
+
+// permute runs the Feistel rounds forward, or backward to invert them.
+func (c *IDCodec) permute(block uint64, inverse bool) uint64 {
+	left, right := uint32(block>>32), uint32(block)
+
+	for i := 0; i < idRounds; i++ {
+		if inverse {
+			round := byte(idRounds - 1 - i)
+			left, right = right^c.round(round, left), left
+		} else {
+			left, right = right, left^c.round(byte(i), right)
+		}
+	}
+
+	return uint64(left)<<32 | uint64(right)
+}
+
+func (c *IDCodec) round(round byte, half uint32) uint32 {
+	mac := hmac.New(sha256.New, c.key)
+	input := [5]byte{round}
+	binary.BigEndian.PutUint32(input[1:], half)
+	mac.Write(input[:])
+	return binary.BigEndian.Uint32(mac.Sum(nil))
+}
+
+var ids = NewIDCodec(defaultIDKey)
+
+// SetIDKey changes the key used by EncodeID, DecodeID and PublicID.
+func SetIDKey(key string) {
+	ids = NewIDCodec(key)
+}
+
+// EncodeID returns the opaque string of an ID, panicking above MaxID like Encode.
+func EncodeID(id uint) string {
+	return ids.Encode(id)
+}
+
+// DecodeID returns the ID of an opaque string, or ErrInvalidID.
+func DecodeID(s string) (uint, error) {
+	return ids.Decode(s)
+}
+
+// PublicID is a numeric ID exposed to clients as an opaque string.
+type PublicID uint
+
+// String returns the opaque string of the ID.
+func (id PublicID) String() string {
+	return EncodeID(uint(id))
+}
+
+// MarshalJSON implements json.Marshaler. It fails with ErrInvalidID above MaxID.
+func (id PublicID) MarshalJSON() ([]byte, error) {
+	if uint64(id) > MaxID {
+		return nil, ErrInvalidID
+	}
+	return json.Marshal(id.String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (id *PublicID) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return ErrInvalidID
+	}
+
+	decoded, err := DecodeID(s)
+	if err != nil {
+		return err
+	}
+	*id = PublicID(decoded)
+	return nil
+}
@@ -0,0 +1,59 @@
+package util
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIDCodecRoundTrip(t *testing.T) {
+	codec := NewIDCodec("test-key")
+
+	seen := map[string]bool{}
+	for _, id := range []uint{0, 1, 2, 3, 42, 1000, 1<<32 + 5, 1<<48 - 1} {
+		encoded := codec.Encode(id)
+		assert.Len(t, encoded, idLength)
+		assert.False(t, seen[encoded], "duplicate encoding for %d", id)
+		seen[encoded] = true
+
+		decoded, err := codec.Decode(encoded)
+		assert.NoError(t, err)
+		assert.Equal(t, id, decoded)
+	}
+}
+
+func TestIDCodecBounds(t *testing.T) {
+	codec := NewIDCodec("test-key")
+
+	// The largest ID round trips, the next one can't be encoded
+	decoded, err := codec.Decode(codec.Encode(MaxID))
+	assert.NoError(t, err)
+	assert.Equal(t, uint(MaxID), decoded)
+	assert.Panics(t, func() { codec.Encode(MaxID + 1) })
+
+	_, err = json.Marshal(PublicID(MaxID + 1))
+	assert.ErrorIs(t, err, ErrInvalidID)
+}
+
+func TestIDCodecRejectsForeignStrings(t *testing.T) {
+	codec := NewIDCodec("test-key")
+	other := NewIDCodec("other-key")
+
+	for _, s := range []string{"", "1", "not-an-id!!", "zzzzzzzzzzz", other.Encode(1)} {
+		_, err := codec.Decode(s)
+		assert.ErrorIs(t, err, ErrInvalidID, s)
+	}
+}
+
+func TestPublicIDJSON(t *testing.T) {
+	data, err := json.Marshal(PublicID(7))
+	assert.NoError(t, err)
+	assert.Equal(t, `"`+EncodeID(7)+`"`, string(data))
+
+	var id PublicID
+	assert.NoError(t, json.Unmarshal(data, &id))
+	assert.Equal(t, PublicID(7), id)
+
+	assert.ErrorIs(t, json.Unmarshal([]byte(`7`), &id), ErrInvalidID)
+}
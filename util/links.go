@@ -0,0 +1,32 @@
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetPageLinks sets the RFC 8288 Link header pointing to the first, previous,
+// next and last pages of response.
+func SetPageLinks(c *gin.Context, response PageResponse) {
+	links := []string{PageLink(c, "page", "1", "first")}
+	if response.Page > 1 {
+		links = append(links, PageLink(c, "page", strconv.Itoa(response.Page-1), "prev"))
+	}
+	if response.Page < response.TotalPages {
+		links = append(links, PageLink(c, "page", strconv.Itoa(response.Page+1), "next"))
+	}
+	if response.TotalPages > 0 {
+		links = append(links, PageLink(c, "page", strconv.Itoa(response.TotalPages), "last"))
+	}
+	c.Header("Link", strings.Join(links, ", "))
+}
+
+// PageLink returns a link to the current request with the query parameter key set to value.
+func PageLink(c *gin.Context, key, value, rel string) string {
+	query := c.Request.URL.Query()
+	query.Set(key, value)
+	return fmt.Sprintf(`<%s?%s>; rel="%s"`, c.Request.URL.Path, query.Encode(), rel)
+}
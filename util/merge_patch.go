@@ -0,0 +1,60 @@
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+// ErrInvalidMergePatch is returned when a merge patch isn't a JSON object.
+var ErrInvalidMergePatch = errors.New("invalid merge patch, expected a JSON object")
+
+// MergePatch applies an RFC 7396 JSON merge patch to a JSON object: the members
+// of the patch replace those of the document, null members remove them, and
+// object members are merged recursively. Numbers are kept exactly.
+func MergePatch(doc, patch []byte) ([]byte, error) {
+	var target map[string]interface{}
+	if err := decodeJSONNumbers(doc, &target); err != nil {
+		return nil, err
+	}
+
+	var changes map[string]interface{}
+	if err := decodeJSONNumbers(patch, &changes); err != nil || changes == nil {
+		return nil, ErrInvalidMergePatch
+	}
+	return json.Marshal(mergePatch(target, changes))
+}
+
+// mergePatch merges a patch value into a target value, as in section 2 of RFC 7396.
+func mergePatch(target, patch interface{}) interface{} {
+	changes, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	object, ok := target.(map[string]interface{})
+	if !ok {
+		object = map[string]interface{}{}
+	}
+	for name, value := range changes {
+		if value == nil {
+			delete(object, name)
+		} else {
+			object[name] = mergePatch(object[name], value)
+		}
+	}
+	return object
+}
+
+// decodeJSONNumbers decodes a single JSON value, reading numbers as json.Number.
+func decodeJSONNumbers(data []byte, value interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(value); err != nil {
+		return err
+	}
+	if decoder.More() {
+		return errors.New("unexpected data after the JSON value")
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergePatch(t *testing.T) {
+	// The examples of appendix A of RFC 7396, with an object document and patch
+	for _, example := range []struct{ doc, patch, expected string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	} {
+		patched, err := MergePatch([]byte(example.doc), []byte(example.patch))
+		assert.NoError(t, err, example.patch)
+		assert.JSONEq(t, example.expected, string(patched), example.patch)
+	}
+
+	// Numbers aren't rounded through floats
+	patched, err := MergePatch([]byte(`{"price":12345678901.12345678,"name":"a"}`), []byte(`{"name":"b"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"name":"b","price":12345678901.12345678}`, string(patched))
+
+	for _, patch := range []string{``, `null`, `[]`, `"a"`, `{"a":1} {}`, `{`} {
+		_, err := MergePatch([]byte(`{"a":"b"}`), []byte(patch))
+		assert.ErrorIs(t, err, ErrInvalidMergePatch, patch)
+	}
+}
@@ -0,0 +1,138 @@
+package util
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MIMEProblem is the content type of RFC 7807 problem details.
+const MIMEProblem = "application/problem+json"
+
+// problemTypePrefix prefixes the code of a problem type to build its URI.
+const problemTypePrefix = "urn:stock-api:problem:"
+
+// ProblemType is an entry of the error catalog: every error response
+// is a problem of one of these types.
+type ProblemType struct {
+	Code   string
+	Status int
+	Title  string
+}
+
+// The error catalog.
+var (
+	ProblemBadRequest           = ProblemType{"BAD_REQUEST", http.StatusBadRequest, "Bad request"}
+	ProblemMalformedJSON        = ProblemType{"JSON_UNMARSHAL", http.StatusBadRequest, "Malformed JSON body"}
+	ProblemUnauthenticated      = ProblemType{"UNAUTHENTICATED", http.StatusUnauthorized, "Unauthenticated"}
+	ProblemInvalidJWT           = ProblemType{"JWT_TOKEN_INVALID", http.StatusUnauthorized, "Invalid access token"}
+	ProblemInvalidAPIKey        = ProblemType{"API_KEY_INVALID", http.StatusUnauthorized, "Invalid API key"}
+	ProblemInvalidCredentials   = ProblemType{"INVALID_CREDENTIALS", http.StatusUnauthorized, "Invalid credentials"}
+	ProblemForbidden            = ProblemType{"FORBIDDEN", http.StatusForbidden, "Forbidden"}
+	ProblemNotFound             = ProblemType{"NOT_FOUND", http.StatusNotFound, "Not found"}
+	ProblemConflict             = ProblemType{"CONFLICT", http.StatusConflict, "Conflict"}
+	ProblemPreconditionFailed   = ProblemType{"PRECONDITION_FAILED", http.StatusPreconditionFailed, "Precondition failed"}
+	ProblemUnsupportedMediaType = ProblemType{"UNSUPPORTED_MEDIA_TYPE", http.StatusUnsupportedMediaType, "Unsupported media type"}
+	ProblemValidation           = ProblemType{"VALIDATION_FAILED", http.StatusUnprocessableEntity, "Validation failed"}
+	ProblemPreconditionRequired = ProblemType{"PRECONDITION_REQUIRED", http.StatusPreconditionRequired, "Precondition required"}
+	ProblemInternal             = ProblemType{"INTERNAL", http.StatusInternalServerError, "Internal server error"}
+	ProblemUnavailable          = ProblemType{"SERVICE_UNAVAILABLE", http.StatusServiceUnavailable, "Service unavailable"}
+)
+
+// URI returns the URI identifying the problem type, e.g. urn:stock-api:problem:not-found.
+func (t ProblemType) URI() string {
+	return problemTypePrefix + strings.ReplaceAll(strings.ToLower(t.Code), "_", "-")
+}
+
+// New returns an error reported to clients as a problem of this type.
+func (t ProblemType) New(detail string) *ProblemError {
+	return &ProblemError{Type: t, Detail: detail}
+}
+
+// Problem is an RFC 7807 problem details object, the body of every error response.
+type Problem struct {
+	Type     string `json:"type" example:"urn:stock-api:problem:not-found"`
+	Title    string `json:"title" example:"Not found"`
+	Status   int    `json:"status" example:"404"`
+	Detail   string `json:"detail,omitempty" example:"Stock not found"`
+	Instance string `json:"instance,omitempty" example:"/api/stocks/8jpBsfQrAre"`
+	// Code is the code of the problem type in the error catalog
+	Code string `json:"code" example:"NOT_FOUND"`
+	// RequestID is the ID of the failed request, to quote when reporting an issue
+	RequestID string `json:"requestId,omitempty"`
+	// Fields lists the invalid fields of a request that failed validation
+	Fields []FieldError `json:"fields,omitempty"`
+}
+
+// ProblemError is an error reported to clients as a problem.
+// Its type is derived from Err when it is zero.
+type ProblemError struct {
+	Type   ProblemType
+	Detail string
+	Fields FieldErrors
+	Err    error
+}
+
+// WithDetail wraps an error with the detail to report to clients,
+// the problem type being derived from err.
+func WithDetail(err error, detail string) *ProblemError {
+	return &ProblemError{Detail: detail, Err: err}
+}
+
+// Error implements error.
+func (e *ProblemError) Error() string {
+	message := e.Detail
+	if message == "" {
+		message = e.Type.Title
+	}
+	if e.Err != nil {
+		return message + ": " + e.Err.Error()
+	}
+	return message
+}
+
+// Unwrap returns the wrapped error.
+func (e *ProblemError) Unwrap() error {
+	return e.Err
+}
+
+// NewProblem returns the problem of a type reported on a request.
+func NewProblem(c *gin.Context, t ProblemType, detail string) Problem {
+	return Problem{
+		Type:     t.URI(),
+		Title:    t.Title,
+		Status:   t.Status,
+		Detail:   detail,
+		Instance: c.Request.URL.Path,
+		Code:     t.Code,
+	}
+}
+
+// WriteProblem writes a problem as the response of a request, and aborts it.
+func WriteProblem(c *gin.Context, problem Problem) {
+	c.Header("Content-Type", MIMEProblem)
+	c.AbortWithStatusJSON(problem.Status, problem)
+}
+
+// AbortWithError aborts a request with an error, which is written as a problem
+// by the Problems middleware. Errors that aren't a ProblemError are mapped
+// from their cause, internal errors are never disclosed.
+func AbortWithError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	var problemErr *ProblemError
+	if errors.As(err, &problemErr) && problemErr.Type.Status != 0 {
+		status = problemErr.Type.Status
+	}
+
+	// the status stands even if the problem isn't written
+	c.Status(status)
+	_ = c.Error(err)
+	c.Abort()
+}
+
+// AbortWithProblem aborts a request with a problem of the catalog.
+func AbortWithProblem(c *gin.Context, t ProblemType, detail string) {
+	AbortWithError(c, t.New(detail))
+}
@@ -0,0 +1,157 @@
+package util
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math/big"
+	"strconv"
+)
+
+// RateScale is the number of decimal places a Rate holds. It is well above
+// DecimalScale so that the rates of weak currencies, e.g. IDR to USD at
+// 0.0000614..., keep their significant digits.
+const RateScale = 18
+
+var (
+	rateOne = new(big.Int).Exp(big.NewInt(10), big.NewInt(RateScale), nil)
+	// rateLimit bounds the units of a Rate to the 38 digits of NUMERIC(38, 18).
+	rateLimit = new(big.Int).Exp(big.NewInt(10), big.NewInt(38), nil)
+)
+
+// Rate is an exact fixed-point number with RateScale decimal places,
+// for the exchange rates prices are multiplied by.
+// The zero value is 0.
+type Rate struct {
+	units *big.Int // value * 10^RateScale
+}
+
+// ParseRate parses a rate such as "0.0000614" or "1.1".
+// It fails with ErrInvalidDecimal when s has more than RateScale decimal
+// places or doesn't fit.
+func ParseRate(s string) (Rate, error) {
+	return parseRate(s, false)
+}
+
+// MustParseRate is ParseRate panicking on invalid numbers, for constants.
+func MustParseRate(s string) Rate {
+	r, err := ParseRate(s)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// parseRate parses s, rounding half away from zero to RateScale places when round is set.
+func parseRate(s string, round bool) (Rate, error) {
+	units, err := parseUnits(s, rateOne, round)
+	if err != nil {
+		return Rate{}, err
+	}
+	if units.CmpAbs(rateLimit) >= 0 {
+		return Rate{}, ErrInvalidDecimal
+	}
+	return Rate{units: units}, nil
+}
+
+// sign returns -1, 0 or +1 depending on the sign of r.
+func (r Rate) sign() int {
+	if r.units == nil {
+		return 0
+	}
+	return r.units.Sign()
+}
+
+// String returns the shortest exact representation of r, e.g. "0.0000614".
+func (r Rate) String() string {
+	if r.units == nil {
+		return "0"
+	}
+	return formatUnits(r.units, RateScale)
+}
+
+// Equal reports whether r and other are the same number.
+func (r Rate) Equal(other Rate) bool {
+	return r.String() == other.String()
+}
+
+// IsZero reports whether r is 0.
+func (r Rate) IsZero() bool {
+	return r.sign() == 0
+}
+
+// IsNegative reports whether r is below 0.
+func (r Rate) IsNegative() bool {
+	return r.sign() < 0
+}
+
+// MulRate returns d * rate rounded half away from zero to DecimalScale places.
+// It fails with ErrInvalidDecimal when the product doesn't fit.
+func (d Decimal) MulRate(rate Rate) (Decimal, error) {
+	if rate.units == nil {
+		return Decimal{}, nil
+	}
+	product := new(big.Int).Mul(big.NewInt(d.units), rate.units)
+	units := roundQuo(product, rateOne)
+	if !units.IsInt64() {
+		return Decimal{}, ErrInvalidDecimal
+	}
+	return Decimal{units: units.Int64()}, nil
+}
+
+// MarshalJSON implements json.Marshaler, writing r as an exact JSON number.
+func (r Rate) MarshalJSON() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, reading a JSON number or string.
+// A JSON null leaves r unchanged.
+func (r *Rate) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	s, err := jsonNumberText(data)
+	if err != nil {
+		return err
+	}
+
+	parsed, err := ParseRate(s)
+	if err != nil {
+		return err
+	}
+	*r = parsed
+	return nil
+}
+
+// Value implements driver.Valuer, sending r as its exact string.
+func (r Rate) Value() (driver.Value, error) {
+	return r.String(), nil
+}
+
+// Scan implements sql.Scanner. Floats read from databases without a decimal
+// type are rounded to RateScale places.
+func (r *Rate) Scan(value interface{}) error {
+	var s string
+	switch v := value.(type) {
+	case nil:
+		*r = Rate{}
+		return nil
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	case int64:
+		s = strconv.FormatInt(v, 10)
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Errorf("%w: can't scan %T", ErrInvalidDecimal, value)
+	}
+
+	parsed, err := parseRate(s, true)
+	if err != nil {
+		return err
+	}
+	*r = parsed
+	return nil
+}
@@ -0,0 +1,93 @@
+package util
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRate(t *testing.T) {
+	for input, expected := range map[string]string{
+		"0.0000614":            "0.0000614",
+		"0.000061435210000000": "0.00006143521",
+		"1.1":                  "1.1",
+		"1e-18":                "0.000000000000000001",
+		"1300":                 "1300",
+		"0":                    "0",
+	} {
+		r, err := ParseRate(input)
+		assert.NoError(t, err, input)
+		assert.Equal(t, expected, r.String(), input)
+	}
+
+	for _, input := range []string{"", "abc", "1/3", "0.0000000000000000001", "100000000000000000000", "NaN"} {
+		_, err := ParseRate(input)
+		assert.ErrorIs(t, err, ErrInvalidDecimal, input)
+	}
+
+	assert.True(t, Rate{}.IsZero())
+	assert.Equal(t, "0", Rate{}.String())
+	assert.True(t, MustParseRate("-0.5").IsNegative())
+	assert.True(t, MustParseRate("0.50").Equal(MustParseRate(".5")))
+}
+
+func TestRateJSON(t *testing.T) {
+	var value struct {
+		Rate Rate `json:"rate"`
+	}
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"rate":0.00006143521}`), &value))
+	assert.Equal(t, "0.00006143521", value.Rate.String())
+	assert.NoError(t, json.Unmarshal([]byte(`{"rate":"1.1"}`), &value))
+	assert.Equal(t, "1.1", value.Rate.String())
+
+	assert.Error(t, json.Unmarshal([]byte(`{"rate":true}`), &value))
+
+	data, err := json.Marshal(value)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"rate":1.1}`, string(data))
+}
+
+func TestRateSQL(t *testing.T) {
+	value, err := MustParseRate("0.0000614").Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "0.0000614", value)
+
+	for _, tc := range []struct {
+		input    interface{}
+		expected string
+	}{
+		{"0.000061435210000000", "0.00006143521"},
+		{[]byte("1.1"), "1.1"},
+		{int64(2), "2"},
+		{0.1 + 0.2, "0.3"},
+	} {
+		var r Rate
+		assert.NoError(t, r.Scan(tc.input), tc.input)
+		assert.Equal(t, tc.expected, r.String(), tc.input)
+	}
+
+	var r Rate
+	assert.ErrorIs(t, r.Scan(true), ErrInvalidDecimal)
+}
+
+func TestDecimalMulRate(t *testing.T) {
+	// Small rates keep every significant digit, a scale 8 rate would round
+	// 0.00006143521 to 0.00006144 and give 61.44
+	price, err := MustParseDecimal("1000000").MulRate(MustParseRate("0.00006143521"))
+	assert.NoError(t, err)
+	assert.Equal(t, "61.43521", price.String())
+
+	// Products are rounded half away from zero
+	price, err = MustParseDecimal("1").MulRate(MustParseRate("0.000000005"))
+	assert.NoError(t, err)
+	assert.Equal(t, "0.00000001", price.String())
+
+	price, err = MustParseDecimal("100").MulRate(Rate{})
+	assert.NoError(t, err)
+	assert.True(t, price.IsZero())
+
+	_, err = MustParseDecimal("90000000000").MulRate(MustParseRate("2"))
+	assert.ErrorIs(t, err, ErrInvalidDecimal)
+}
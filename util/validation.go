@@ -0,0 +1,119 @@
+package util
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ErrMalformedJSON is returned when a request body isn't a JSON object.
+var ErrMalformedJSON = errors.New("malformed JSON, expected an object")
+
+// FieldError tells why the value of a request field is invalid.
+type FieldError struct {
+	Field   string `json:"field" example:"name"`
+	Message string `json:"message" example:"is required"`
+}
+
+// FieldErrors collects the invalid fields of a request.
+type FieldErrors []FieldError
+
+// Add records that a field is invalid.
+func (e *FieldErrors) Add(field, message string) {
+	*e = append(*e, FieldError{Field: field, Message: message})
+}
+
+// Has reports whether a field is already invalid.
+func (e FieldErrors) Has(field string) bool {
+	for _, err := range e {
+		if err.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// DecodeJSONFields decodes a JSON object into the struct dst points to one field at a time,
+// so that every field holding a value of the wrong type is reported instead of the first one.
+// Fields are matched by their JSON name like encoding/json does, unknown members are ignored.
+// It returns ErrMalformedJSON when data isn't a JSON object.
+func DecodeJSONFields(data []byte, dst interface{}) (FieldErrors, error) {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil || members == nil {
+		return nil, ErrMalformedJSON
+	}
+
+	value := reflect.ValueOf(dst).Elem()
+	fields := jsonFieldIndexes(value.Type())
+
+	var errs FieldErrors
+	for name, raw := range members {
+		index, ok := fields[name]
+		if !ok {
+			for fieldName, fieldIndex := range fields {
+				if strings.EqualFold(fieldName, name) {
+					name, index, ok = fieldName, fieldIndex, true
+					break
+				}
+			}
+		}
+		if !ok {
+			continue
+		}
+
+		field := value.Field(index)
+		if err := json.Unmarshal(raw, field.Addr().Interface()); err != nil {
+			errs.Add(name, "must be "+describeJSONType(field.Type()))
+		}
+	}
+	return errs, nil
+}
+
+// jsonFieldIndexes maps the JSON names of the exported fields of a struct to their index.
+func jsonFieldIndexes(t reflect.Type) map[string]int {
+	fields := map[string]int{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := field.Name
+		if tag, _, _ := strings.Cut(field.Tag.Get("json"), ","); tag == "-" {
+			continue
+		} else if tag != "" {
+			name = tag
+		}
+		fields[name] = i
+	}
+	return fields
+}
+
+// describeJSONType names the JSON values a field accepts, for error messages.
+func describeJSONType(t reflect.Type) string {
+	switch t {
+	case reflect.TypeOf(time.Time{}):
+		return "an RFC 3339 date-time"
+	case reflect.TypeOf(Decimal{}):
+		return "a decimal number"
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	case reflect.Ptr:
+		return describeJSONType(t.Elem())
+	}
+	return "a valid value"
+}
@@ -0,0 +1,43 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeJSONFields(t *testing.T) {
+	type request struct {
+		Name     string    `json:"name"`
+		Price    Decimal   `json:"price"`
+		At       time.Time `json:"at"`
+		Count    int
+		Internal string `json:"-"`
+	}
+
+	// Every valid field is decoded, names ignore case
+	var value request
+	errs, err := DecodeJSONFields([]byte(`{"NAME":"Apple","price":"10.5","at":"2024-01-02T03:04:05Z","count":3,"Internal":"x","other":true}`), &value)
+	assert.NoError(t, err)
+	assert.Empty(t, errs)
+	assert.Equal(t, request{Name: "Apple", Price: MustParseDecimal("10.5"), At: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), Count: 3}, value)
+
+	// Every invalid field is reported
+	value = request{}
+	errs, err = DecodeJSONFields([]byte(`{"name":1,"price":"cheap","at":"yesterday","Count":"3"}`), &value)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, FieldErrors{
+		{Field: "name", Message: "must be a string"},
+		{Field: "price", Message: "must be a decimal number"},
+		{Field: "at", Message: "must be an RFC 3339 date-time"},
+		{Field: "Count", Message: "must be a number"},
+	}, errs)
+	assert.True(t, errs.Has("price"))
+	assert.False(t, errs.Has("other"))
+
+	for _, data := range []string{``, `null`, `[]`, `"a"`, `{`} {
+		_, err := DecodeJSONFields([]byte(data), &value)
+		assert.ErrorIs(t, err, ErrMalformedJSON, data)
+	}
+}